@@ -0,0 +1,293 @@
+package mkversions
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/macho"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNoVersionMetadata возвращается, если в файле нет ни Go build info,
+// ни ресурса VERSIONINFO, ни данных mkversions
+var ErrNoVersionMetadata = errors.New("no version metadata found")
+
+// Данные mkversions хранятся в виде ELF-заметок (namesz/descsz/type/name/desc)
+// в отдельной секции, имя которой зависит от формата файла
+const (
+	noteOwner = "mkversions"
+
+	// noteTypeInfo - описание заметки содержит Info в формате JSON
+	noteTypeInfo = 0x7f000000
+	// noteTypeInfoLegacy - прежний тип заметки с Info, readelf -n выводит его как OPEN
+	noteTypeInfoLegacy = 0x100
+
+	elfNoteSection   = ".note.mkversions"
+	peNoteSection    = ".mkvers"
+	machoNoteSection = "__mkversions"
+
+	goBuildIDNoteSection = ".note.go.buildid"
+)
+
+// binaryMetadata - сведения о версии, найденные в исполняемом файле
+type binaryMetadata struct {
+	notes     []byte
+	order     binary.ByteOrder
+	version   *VersionResource
+	goBuildID string
+	// resourceErr - ошибка разбора ресурсов PE; остальные источники при этом читаются
+	resourceErr error
+}
+
+// ReadInfoFromBinary восстанавливает Info из собранного файла ELF, PE или Mach-O.
+// Используются Go build info, строки VERSIONINFO (PE) и секция mkversions.
+// Если ничего из этого нет, возвращается ошибка ErrNoVersionMetadata.
+func ReadInfoFromBinary(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var magic [4]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var meta *binaryMetadata
+	switch {
+	case bytes.Equal(magic[:], []byte(elf.ELFMAG)):
+		meta, err = readELFMetadata(f)
+	case magic[0] == 'M' && magic[1] == 'Z':
+		meta, err = readPEMetadata(f)
+	case isMachO(magic):
+		meta, err = readMachOMetadata(f)
+	default:
+		return nil, fmt.Errorf("%s: unsupported binary format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var info *Info
+	if meta.notes != nil {
+		info, err = infoFromNotes(meta.notes, meta.order)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	bi, biErr := buildinfo.Read(f)
+	if info == nil && biErr != nil && meta.version == nil {
+		if meta.resourceErr != nil {
+			return nil, fmt.Errorf("%w in %s: %v", ErrNoVersionMetadata, path, meta.resourceErr)
+		}
+		return nil, fmt.Errorf("%w in %s", ErrNoVersionMetadata, path)
+	}
+
+	if info == nil {
+		if biErr == nil {
+			info = infoFromBuildInfo(bi)
+		} else {
+			info = &Info{GITInfo: &GITInfo{}, AppMetadata: &AppMetadata{}}
+		}
+		if meta.version != nil {
+			applyVersionResource(info, meta.version)
+		}
+		if meta.goBuildID != "" {
+			info.BuildID = meta.goBuildID
+		}
+	} else if biErr == nil {
+		// Данные mkversions полнее, но зависимости и настройки сборки берем из build info
+		fromBuild := infoFromBuildInfo(bi)
+		if len(info.Dependencies) == 0 {
+			info.Dependencies = fromBuild.Dependencies
+		}
+		if len(info.DependencySums) == 0 {
+			info.DependencySums = fromBuild.DependencySums
+		}
+		if len(info.BuildSettings) == 0 {
+			info.BuildSettings = fromBuild.BuildSettings
+		}
+	}
+
+	if meta.resourceErr != nil {
+		info.addWarning("VersionResource", meta.resourceErr)
+	}
+	info.ValidVersion = IsValidSemVer(info.Version)
+	return info, nil
+}
+
+func isMachO(magic [4]byte) bool {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		switch order.Uint32(magic[:]) {
+		case macho.Magic32, macho.Magic64, macho.MagicFat:
+			return true
+		}
+	}
+	return false
+}
+
+func readELFMetadata(r io.ReaderAt) (*binaryMetadata, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ELF file: %v", err)
+	}
+
+	meta := &binaryMetadata{order: f.ByteOrder}
+	if sec := f.Section(elfNoteSection); sec != nil {
+		if meta.notes, err = sec.Data(); err != nil {
+			return nil, fmt.Errorf("failed to read section %s: %v", elfNoteSection, err)
+		}
+	}
+	if sec := f.Section(goBuildIDNoteSection); sec != nil {
+		if data, err := sec.Data(); err == nil {
+			for _, n := range parseNotes(data, f.ByteOrder) {
+				if n.name == "Go" && n.typ == 4 {
+					meta.goBuildID = string(n.desc)
+				}
+			}
+		}
+	}
+	return meta, nil
+}
+
+func readPEMetadata(r io.ReaderAt) (*binaryMetadata, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, 1<<62))
+	if err != nil {
+		return nil, err
+	}
+	img, err := parsePEImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &binaryMetadata{order: binary.LittleEndian}
+	if sec := img.file.Section(peNoteSection); sec != nil {
+		if meta.notes, err = sec.Data(); err != nil {
+			return nil, fmt.Errorf("failed to read section %s: %v", peNoteSection, err)
+		}
+	}
+
+	rs, err := img.resources()
+	if err != nil {
+		meta.resourceErr = err
+		return meta, nil
+	}
+	if entry := rs.find(resourceID{ID: rtVersion}); entry != nil {
+		if meta.version, err = parseVersionResource(entry.Data); err != nil {
+			meta.resourceErr = err
+		}
+	}
+	return meta, nil
+}
+
+func readMachOMetadata(r io.ReaderAt) (*binaryMetadata, error) {
+	f, err := macho.NewFile(r)
+	if err != nil {
+		fat, fatErr := macho.NewFatFile(r)
+		if fatErr != nil {
+			return nil, fmt.Errorf("failed to parse Mach-O file: %v", err)
+		}
+		if len(fat.Arches) == 0 {
+			return nil, fmt.Errorf("empty universal Mach-O file")
+		}
+		f = fat.Arches[0].File
+	}
+
+	meta := &binaryMetadata{order: f.ByteOrder}
+	if sec := f.Section(machoNoteSection); sec != nil {
+		if meta.notes, err = sec.Data(); err != nil {
+			return nil, fmt.Errorf("failed to read section %s: %v", machoNoteSection, err)
+		}
+	}
+	return meta, nil
+}
+
+// applyVersionResource переносит строки VERSIONINFO в Info
+func applyVersionResource(info *Info, vr *VersionResource) {
+	if info.AppMetadata == nil {
+		info.AppMetadata = &AppMetadata{}
+	}
+	if len(vr.StringTables) == 0 {
+		if info.Version == "" {
+			v := vr.FileVersion
+			info.Version = fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+		}
+		return
+	}
+
+	strs := vr.StringTables[0].Strings
+	if v := strs["FileVersion"]; v != "" {
+		info.Version = v
+	}
+	info.ProductVersion = strs["ProductVersion"]
+	info.ProgramName = strs["ProductName"]
+	info.Description = strs["FileDescription"]
+	info.Legal = strs["LegalCopyright"]
+	info.CompanyName = strs["CompanyName"]
+	info.InternalName = strs["InternalName"]
+
+	for _, st := range vr.StringTables {
+		info.Translations = append(info.Translations, VersionTranslation{Language: st.Language, CodePage: st.CodePage})
+	}
+}
+
+type note struct {
+	name string
+	typ  uint32
+	desc []byte
+}
+
+// parseNotes разбирает последовательность заметок формата ELF с выравниванием по 4 байта
+func parseNotes(data []byte, order binary.ByteOrder) []note {
+	var notes []note
+	for len(data) >= 12 {
+		namesz := int(order.Uint32(data[0:]))
+		descsz := int(order.Uint32(data[4:]))
+		typ := order.Uint32(data[8:])
+		data = data[12:]
+
+		nameEnd := alignInt(namesz, 4)
+		if namesz < 0 || descsz < 0 || nameEnd > len(data) || nameEnd+descsz > len(data) {
+			break
+		}
+		name := strings.TrimRight(string(data[:namesz]), "\x00")
+		desc := data[nameEnd : nameEnd+descsz]
+		notes = append(notes, note{name: name, typ: typ, desc: desc})
+
+		next := nameEnd + alignInt(descsz, 4)
+		if next > len(data) {
+			break
+		}
+		data = data[next:]
+	}
+	return notes
+}
+
+// infoFromNotes ищет заметку mkversions с JSON описанием Info
+func infoFromNotes(data []byte, order binary.ByteOrder) (*Info, error) {
+	for _, n := range parseNotes(data, order) {
+		if n.name != noteOwner || n.typ != noteTypeInfo && n.typ != noteTypeInfoLegacy {
+			continue
+		}
+		info := &Info{}
+		if err := json.Unmarshal(n.desc, info); err != nil {
+			return nil, fmt.Errorf("failed to decode mkversions note: %v", err)
+		}
+		if info.GITInfo == nil {
+			info.GITInfo = &GITInfo{}
+		}
+		if info.AppMetadata == nil {
+			info.AppMetadata = &AppMetadata{}
+		}
+		return info, nil
+	}
+	return nil, fmt.Errorf("section does not contain a mkversions note")
+}
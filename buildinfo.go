@@ -0,0 +1,84 @@
+package mkversions
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// NewInfoFromBuildInfo создает Info по данным, которые компилятор Go встроил
+// в исполняемый файл (debug.ReadBuildInfo). Внешние процессы не запускаются.
+func NewInfoFromBuildInfo(opts ...Option) (*Info, error) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, fmt.Errorf("build info is not available in this binary")
+	}
+
+	info := infoFromBuildInfo(bi)
+	info.BuildID = generateBuildID()
+	applyLinkerVars(info)
+
+	for _, opt := range opts {
+		opt(info)
+	}
+
+	info.DetailedVersion = fmt.Sprintf("%s-%s(%s) | %s", info.Version, info.GITInfo.CommitHashShort, info.ReleaseType, info.GITInfo.CommitDate.Format("2006-01-02"))
+	info.ValidVersion = IsValidSemVer(info.Version)
+	return info, nil
+}
+
+// infoFromBuildInfo переносит данные debug.BuildInfo в Info
+func infoFromBuildInfo(bi *debug.BuildInfo) *Info {
+	info := &Info{
+		Version:        strings.TrimPrefix(bi.Main.Version, "v"),
+		GoVersion:      bi.GoVersion,
+		Dependencies:   make(map[string]string),
+		DependencySums: make(map[string]string),
+		BuildSettings:  make(map[string]string),
+		GITInfo:        &GITInfo{},
+		AppMetadata:    &AppMetadata{},
+		tagPrefix:      "v",
+	}
+
+	for _, dep := range bi.Deps {
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		version := mod.Version
+		if version == "" {
+			version = mod.Path
+		}
+		info.Dependencies[dep.Path] = version
+		if mod.Sum != "" {
+			info.DependencySums[dep.Path] = mod.Sum
+		}
+	}
+
+	for _, setting := range bi.Settings {
+		info.BuildSettings[setting.Key] = setting.Value
+
+		switch setting.Key {
+		case "vcs.revision":
+			info.GITInfo.CommitHash = setting.Value
+			if len(setting.Value) > 7 {
+				info.GITInfo.CommitHashShort = setting.Value[:7]
+			} else {
+				info.GITInfo.CommitHashShort = setting.Value
+			}
+		case "vcs.time":
+			if date, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				info.GITInfo.CommitDate = date
+			}
+		case "vcs.modified":
+			info.GITInfo.IsDirty = setting.Value == "true"
+		case "GOOS":
+			info.Platform = setting.Value
+		case "GOARCH":
+			info.Architecture = setting.Value
+		}
+	}
+
+	return info
+}
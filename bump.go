@@ -0,0 +1,163 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BumpType описывает, какую часть версии нужно увеличить
+type BumpType int
+
+const (
+	BumpNone BumpType = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b BumpType) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// VersionBump содержит результат вычисления следующей версии
+type VersionBump struct {
+	Tag         string
+	Current     string
+	Next        string
+	Bump        BumpType
+	CommitCount int
+}
+
+// Bump возвращает новую версию, увеличенную согласно b.
+// Pre-release и метаданные сборки сбрасываются.
+func (v *SemVer) Bump(b BumpType) *SemVer {
+	next := &SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch b {
+	case BumpMajor:
+		next.Major++
+		next.Minor = 0
+		next.Patch = 0
+	case BumpMinor:
+		next.Minor++
+		next.Patch = 0
+	case BumpPatch:
+		next.Patch++
+	default:
+		next.Prerelease = v.Prerelease
+		next.Build = v.Build
+	}
+	return next
+}
+
+// ClassifyCommit определяет тип увеличения версии для сообщения коммита
+// по правилам Conventional Commits
+func ClassifyCommit(message string) BumpType {
+	cc, err := ParseConventionalCommit(message)
+	if err != nil {
+		return BumpNone
+	}
+	return cc.Bump()
+}
+
+// NextVersion вычисляет следующую версию по коммитам, сделанным после
+// последнего релизного тега с префиксом tagPrefix, достижимого из ref.
+// Если заданы paths, учитываются только коммиты, затрагивающие их.
+func NextVersion(tagPrefix, ref string, paths ...string) (*VersionBump, error) {
+	return NextVersionContext(context.Background(), tagPrefix, ref, paths...)
+}
+
+// NextVersionContext работает как NextVersion с контекстом
+func NextVersionContext(ctx context.Context, tagPrefix, ref string, paths ...string) (*VersionBump, error) {
+	return defaultRepo.NextVersion(ctx, tagPrefix, ref, paths...)
+}
+
+// NextVersion работает как пакетная NextVersion для этого репозитория
+func (r *Repo) NextVersion(ctx context.Context, tagPrefix, ref string, paths ...string) (*VersionBump, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	tag, current, err := r.lastReleaseTag(ctx, tagPrefix, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeArg := ref
+	if tag != "" {
+		rangeArg = tag + ".." + ref
+	}
+
+	args := append([]string{"log", "--no-merges", "--format=%B%x1e", rangeArg, "--"}, paths...)
+	stdout, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, wrapGitError(fmt.Sprintf("failed to get Git commits since %q", tag), err)
+	}
+
+	result := &VersionBump{Tag: tag, Current: current.String()}
+	for _, message := range strings.Split(stdout, "\x1e") {
+		if strings.TrimSpace(message) == "" {
+			continue
+		}
+		result.CommitCount++
+		if b := ClassifyCommit(message); b > result.Bump {
+			result.Bump = b
+		}
+	}
+
+	result.Next = current.Bump(result.Bump).String()
+	return result, nil
+}
+
+// PreviousReleaseTag возвращает релизный тег с префиксом tagPrefix и наибольшей
+// версией, достижимый из ref (по умолчанию HEAD), не считая тегов на самом ref.
+// Если такого тега нет (первый релиз), возвращается пустая строка.
+func (r *Repo) PreviousReleaseTag(ctx context.Context, tagPrefix, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	tag, _, err := r.releaseTag(ctx, tagPrefix, "--merged", ref, "--no-contains", ref)
+	if errors.Is(err, ErrNoCommits) {
+		return "", nil
+	}
+	return tag, err
+}
+
+// lastReleaseTag ищет тег с наибольшей релизной (без pre-release) версией,
+// достижимый из ref. Если тегов нет, возвращается пустой тег и версия 0.0.0.
+func (r *Repo) lastReleaseTag(ctx context.Context, tagPrefix, ref string) (string, *SemVer, error) {
+	return r.releaseTag(ctx, tagPrefix, "--merged", ref)
+}
+
+// releaseTag выбирает тег с наибольшей релизной версией среди git tag filter...
+func (r *Repo) releaseTag(ctx context.Context, tagPrefix string, filter ...string) (string, *SemVer, error) {
+	stdout, err := r.run(ctx, append([]string{"tag"}, filter...)...)
+	if err != nil {
+		return "", nil, wrapGitError("failed to list Git tags", err)
+	}
+
+	var bestTag string
+	best := &SemVer{}
+	for _, tag := range strings.Fields(stdout) {
+		if !strings.HasPrefix(tag, tagPrefix) {
+			continue
+		}
+		v, err := ParseSemVer(strings.TrimPrefix(tag, tagPrefix))
+		if err != nil || v.IsPrerelease() {
+			continue
+		}
+		if bestTag == "" || v.Compare(best) > 0 {
+			bestTag, best = tag, v
+		}
+	}
+	return bestTag, best, nil
+}
@@ -0,0 +1,100 @@
+package mkversions
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// gitRunnerFunc позволяет подменять git в тестах функцией
+type gitRunnerFunc func(ctx context.Context, args ...string) (string, error)
+
+func (f gitRunnerFunc) Run(ctx context.Context, args ...string) (string, error) {
+	return f(ctx, args...)
+}
+
+func TestClassifyCommit(t *testing.T) {
+	tests := []struct {
+		message string
+		want    BumpType
+	}{
+		{"feat: add list", BumpMinor},
+		{"feat(api): add list", BumpMinor},
+		{"FEAT: upper-case type", BumpMinor},
+		{"fix: crash", BumpPatch},
+		{"fix(parser): crash on empty input", BumpPatch},
+		{"feat!: drop v1 API", BumpMajor},
+		{"fix(api)!: rename field", BumpMajor},
+		{"refactor!: new layout", BumpMajor},
+		{"feat: x\n\nBREAKING CHANGE: api gone", BumpMajor},
+		{"chore: x\n\nBREAKING-CHANGE: config renamed", BumpMajor},
+		{"docs: readme", BumpNone},
+		{"chore(deps): bump", BumpNone},
+		{"perf: faster", BumpNone},
+		{"Merge branch 'main'", BumpNone},
+		{"feat:missing space", BumpNone},
+		{"fix: breaking change mentioned in lower case", BumpPatch},
+	}
+	for _, tt := range tests {
+		if got := ClassifyCommit(tt.message); got != tt.want {
+			t.Errorf("ClassifyCommit(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     string
+		messages []string
+		tag      string
+		current  string
+		next     string
+		bump     BumpType
+	}{
+		{"patch", "v1.2.3\n", []string{"fix: a", "docs: b"}, "v1.2.3", "1.2.3", "1.2.4", BumpPatch},
+		{"minor resets patch", "v1.2.3\n", []string{"fix: a", "feat: b"}, "v1.2.3", "1.2.3", "1.3.0", BumpMinor},
+		{"breaking footer", "v1.2.3\n", []string{"feat: a\n\nBREAKING CHANGE: gone", "fix: b"}, "v1.2.3", "1.2.3", "2.0.0", BumpMajor},
+		{"breaking bang", "v1.2.3\n", []string{"fix!: a"}, "v1.2.3", "1.2.3", "2.0.0", BumpMajor},
+		{"no relevant commits", "v1.2.3\n", []string{"docs: a", "chore: b"}, "v1.2.3", "1.2.3", "1.2.3", BumpNone},
+		{"highest release tag", "v1.10.0\nv1.9.0\nv2.0.0-rc.1\nother\nv1.x\n", []string{"fix: a"}, "v1.10.0", "1.10.0", "1.10.1", BumpPatch},
+		// Без тегов отсчет идет от 0.0.0
+		{"first release", "", []string{"feat: a"}, "", "0.0.0", "0.1.0", BumpMinor},
+		// В версиях 0.x правила те же: несовместимое изменение дает 1.0.0
+		{"0.x feat", "v0.3.1\n", []string{"feat: a"}, "v0.3.1", "0.3.1", "0.4.0", BumpMinor},
+		{"0.x fix", "v0.3.1\n", []string{"fix: a"}, "v0.3.1", "0.3.1", "0.3.2", BumpPatch},
+		{"0.x breaking", "v0.3.1\n", []string{"feat!: a"}, "v0.3.1", "0.3.1", "1.0.0", BumpMajor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logRange string
+			repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+				switch args[0] {
+				case "tag":
+					return tt.tags, nil
+				case "log":
+					logRange = args[3]
+					return strings.Join(tt.messages, "\n\x1e") + "\n\x1e", nil
+				}
+				t.Fatalf("unexpected git %v", args)
+				return "", nil
+			}))
+
+			got, err := repo.NextVersion(context.Background(), "v", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &VersionBump{Tag: tt.tag, Current: tt.current, Next: tt.next, Bump: tt.bump, CommitCount: len(tt.messages)}
+			if *got != *want {
+				t.Errorf("NextVersion = %+v, want %+v", got, want)
+			}
+			wantRange := "HEAD"
+			if tt.tag != "" {
+				wantRange = tt.tag + "..HEAD"
+			}
+			if logRange != wantRange {
+				t.Errorf("git log range = %q, want %q", logRange, wantRange)
+			}
+		})
+	}
+}
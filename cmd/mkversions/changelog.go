@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runChangelog(args []string, stdout io.Writer) error {
+	fs := newFlagSet("changelog")
+	since := fs.String("since", "", "show commits more recent than a date, e.g. 2024-01-31")
+	ref := fs.String("ref", "", "git ref to read the log up to; HEAD when empty")
+	from := fs.String("from", "", "show commits after this ref; the previous release tag when empty")
+	tagPrefix := fs.String("tag-prefix", "v", "prefix of release tags used to find the previous release")
+	dir := fs.String("C", "", "run git in this directory instead of the current one")
+	module := fs.String("module", "", "module directory relative to the repository root; limits commits and release tags to it")
+	paths := fs.String("path", "", "comma-separated pathspecs; show only commits touching them")
+	format := fs.String("format", "markdown", "output format: text, json, markdown or html")
+	group := fs.Bool("group", false, "group commits by Conventional Commits type (markdown, html and json formats)")
+	issue := fs.String("issue", "", "show only commits referencing these comma-separated issues, e.g. #12,PROJ-34")
+	issueURL := fs.String("issue-url", "", "link template for #123 references, e.g. https://github.com/owner/repo/issues/{id}")
+	jiraURL := fs.String("jira-url", "", "link template for Jira keys, e.g. https://example.atlassian.net/browse/{id}")
+	contributors := fs.Bool("contributors", false, "append a Contributors section using .mailmap (text, markdown and html formats)")
+	jiraProjects := fs.String("jira-projects", "", "comma-separated Jira project keys; any key when empty")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo := mkversions.OpenRepo(*dir)
+	pathspecs := splitList(*paths)
+	prefix := *tagPrefix
+	if *module != "" {
+		modulePaths, err := repo.ModulePathspec(ctx, *module)
+		if err != nil {
+			return err
+		}
+		pathspecs = append(pathspecs, modulePaths...)
+		if moduleDir := strings.Trim(path.Clean(filepath.ToSlash(*module)), "/"); moduleDir != "." {
+			prefix = moduleDir + "/" + prefix
+		}
+	}
+
+	var changelog *mkversions.Changelog
+	var err error
+	switch {
+	case *since != "" && *contributors:
+		changelog, err = repo.ChangelogStats(ctx, *since, *ref, pathspecs...)
+	case *since != "":
+		changelog, err = repo.Changelog(ctx, *since, *ref, pathspecs...)
+	default:
+		start := *from
+		if start == "" {
+			if start, err = repo.PreviousReleaseTag(ctx, prefix, *ref); err != nil {
+				return err
+			}
+		}
+		if *contributors {
+			changelog, err = repo.ChangelogRangeStats(ctx, start, *ref, pathspecs...)
+		} else {
+			changelog, err = repo.ChangelogRange(ctx, start, *ref, pathspecs...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	patterns := []mkversions.ReferencePattern{mkversions.IssueReferencePattern(*issueURL)}
+	if *jiraURL != "" || *jiraProjects != "" {
+		patterns = append(patterns, mkversions.JiraReferencePattern(*jiraURL, splitList(*jiraProjects)...))
+	}
+	changelog.ExtractReferences(patterns)
+	if *issue != "" {
+		changelog = changelog.FilterByReference(splitList(*issue)...)
+	}
+
+	if *contributors && *format == "json" {
+		return fmt.Errorf("%w: -contributors is not supported with the json format", errUsage)
+	}
+	// mailmap читается до вывода, чтобы ошибка не оставила журнал без раздела
+	var mailmap *mkversions.Mailmap
+	if *contributors {
+		if mailmap, err = repo.Mailmap(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := writeChangelog(stdout, changelog, *format, *group); err != nil {
+		return err
+	}
+	if *contributors {
+		return writeContributors(stdout, changelog.Contributors(mailmap), *format)
+	}
+	return nil
+}
+
+func writeChangelog(w io.Writer, changelog *mkversions.Changelog, format string, group bool) error {
+	if group && format != "text" {
+		return writeGroupedChangelog(w, changelog.Group(nil), format)
+	}
+
+	switch format {
+	case "text":
+		for _, entry := range changelog.Entries {
+			fmt.Fprintln(w, entry)
+		}
+	case "json":
+		data, err := changelog.ToJSON()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, data)
+	case "markdown", "md":
+		fmt.Fprint(w, changelog.ToMarkdown())
+	case "html":
+		fmt.Fprint(w, changelog.ToHTML())
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+	return nil
+}
+
+func writeGroupedChangelog(w io.Writer, grouped *mkversions.GroupedChangelog, format string) error {
+	switch format {
+	case "json":
+		data, err := grouped.ToJSON()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, data)
+	case "markdown", "md":
+		fmt.Fprint(w, grouped.ToMarkdown())
+	case "html":
+		fmt.Fprint(w, grouped.ToHTML())
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+	return nil
+}
+
+func writeContributors(w io.Writer, contributors mkversions.Contributors, format string) error {
+	switch format {
+	case "text":
+		for _, c := range contributors {
+			fmt.Fprintf(w, "%s <%s> %d +%d -%d\n", c.Name, c.Email, c.Commits, c.Additions, c.Deletions)
+		}
+	case "markdown", "md":
+		fmt.Fprint(w, "\n"+contributors.ToMarkdown())
+	case "html":
+		fmt.Fprint(w, contributors.ToHTML())
+	default:
+		return fmt.Errorf("%w: -contributors is not supported with the %s format", errUsage, format)
+	}
+	return nil
+}
+
+// splitList разбивает список через запятую, пропуская пустые элементы
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
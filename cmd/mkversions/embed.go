@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func runEmbed(args []string, stdout io.Writer) error {
+	fs := newFlagSet("embed")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected path to an ELF binary", errUsage)
+	}
+
+	path := fs.Arg(0)
+	info := flags.info()
+	if err := info.WriteELFNote(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote version %s to %s\n", info.Version, path)
+	return nil
+}
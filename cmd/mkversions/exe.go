@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func runExe(args []string, stdout io.Writer) error {
+	fs := newFlagSet("exe")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected path to a Windows executable", errUsage)
+	}
+
+	path := fs.Arg(0)
+	info := flags.info()
+	if err := info.WriteExeMetadata(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote version %s to %s\n", info.Version, path)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runGenerate(args []string, stdout io.Writer) error {
+	fs := newFlagSet("generate")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file; $GOPACKAGE when run by go generate")
+	output := fs.String("o", "version_gen.go", "output file")
+	reproducible := fs.Bool("reproducible", true, "derive build date and build ID from the commit so unchanged sources give an identical file")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *pkg == "" {
+		return fmt.Errorf("%w: -pkg is required outside of go generate", errUsage)
+	}
+
+	// Сгенерированный файл не должен делать рабочую копию "грязной"
+	info := flags.info(mkversions.WithDirtyExclude(*output))
+	if *reproducible {
+		sum := sha256.Sum256([]byte(info.CommitHash + info.DiffHash))
+		info.SetInfo(
+			mkversions.WithBuildDate(info.CommitDate),
+			mkversions.WithBuildID(hex.EncodeToString(sum[:16])),
+		)
+		info.DetailedVersion = fmt.Sprintf("%s-%s(%s) | %s", info.Version, info.CommitHashShort, info.ReleaseType, info.CommitDate.Format("2006-01-02"))
+	}
+
+	return info.GenerateGoFile(*pkg, *output)
+}
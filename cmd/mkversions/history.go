@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runHistory(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected subcommand list, show or add", errUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return runHistoryList(args[1:], stdout)
+	case "show":
+		return runHistoryShow(args[1:], stdout)
+	case "add":
+		return runHistoryAdd(args[1:], stdout)
+	}
+	return fmt.Errorf("%w: unknown history subcommand %q", errUsage, args[0])
+}
+
+func runHistoryList(args []string, stdout io.Writer) error {
+	fs := newFlagSet("history list")
+	file := fs.String("file", "build_history.json", "build history file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	bh, err := mkversions.LoadBuildHistoryFromFile(*file)
+	if err != nil {
+		return err
+	}
+
+	for i, build := range bh.ListBuilds() {
+		commit := ""
+		if build.GITInfo != nil {
+			commit = build.CommitHashShort
+		}
+		fmt.Fprintf(stdout, "%d\t%s\t%s\t%s\t%s\n", i, build.Version, commit, build.ReleaseType, build.BuildDate.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+func runHistoryShow(args []string, stdout io.Writer) error {
+	fs := newFlagSet("history show")
+	file := fs.String("file", "build_history.json", "build history file")
+	index := fs.Int("index", -1, "build index; latest build when negative")
+	format := fs.String("format", "text", "output format: text, json or markdown")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	bh, err := mkversions.LoadBuildHistoryFromFile(*file)
+	if err != nil {
+		return err
+	}
+
+	var build *mkversions.Info
+	if *index < 0 {
+		build = bh.GetLatestBuild()
+		if build == nil {
+			return fmt.Errorf("build history %s is empty", *file)
+		}
+	} else {
+		build, err = bh.GetBuildByIndex(*index)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+	}
+
+	return formatInfo(stdout, build, *format)
+}
+
+func runHistoryAdd(args []string, stdout io.Writer) error {
+	fs := newFlagSet("history add")
+	file := fs.String("file", "build_history.json", "build history file")
+	limit := fs.Int("limit", 10, "maximum number of builds kept in a new history file")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *limit < 1 {
+		return fmt.Errorf("%w: -limit must be at least 1", errUsage)
+	}
+
+	bh, err := mkversions.LoadBuildHistoryFromFile(*file)
+	if err != nil {
+		if _, statErr := os.Stat(*file); !errors.Is(statErr, os.ErrNotExist) {
+			return err
+		}
+		bh = mkversions.NewBuildHistory(*limit)
+	}
+
+	info := flags.info()
+	info.SaveToHistory(bh)
+	if err := bh.SaveToFile(*file); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "added build %s (%s) to %s\n", info.Version, info.BuildID, *file)
+	return nil
+}
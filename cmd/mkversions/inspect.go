@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runInspect(args []string, stdout io.Writer) error {
+	fs := newFlagSet("inspect")
+	format := fs.String("format", "text", "output format: text, json or markdown")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected path to a binary", errUsage)
+	}
+
+	info, err := mkversions.ReadInfoFromBinary(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return formatInfo(stdout, info, *format)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func runLDFlags(args []string, stdout io.Writer) error {
+	fs := newFlagSet("ldflags")
+	pkg := fs.String("pkg", "", "package path holding the version variables; mkversions itself when empty")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, flags.info().LDFlags(*pkg))
+	return nil
+}
@@ -0,0 +1,189 @@
+// Команда mkversions выводит информацию о версии сборки, журнал изменений,
+// историю сборок и флаги компоновщика, а также генерирует Go-файл с версией.
+//
+// Коды завершения: 0 - успех, 1 - ошибка выполнения, 2 - неверные аргументы,
+// 3 - в файле не найдены метаданные версии.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitUsage  = 2
+	exitNoData = 3
+)
+
+// errUsage означает, что команда вызвана с неверными аргументами
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"show", "print version info as text, json or markdown", runShow},
+	{"changelog", "print git changelog", runChangelog},
+	{"release", "add a release section to a Keep a Changelog file", runRelease},
+	{"modules", "print version info for each Go module of the repository", runModules},
+	{"history", "list, show and add builds in a build history file", runHistory},
+	{"ldflags", "print -ldflags for go build", runLDFlags},
+	{"generate", "write a Go file with embedded version info", runGenerate},
+	{"exe", "write VERSIONINFO resource into a Windows executable", runExe},
+	{"syso", "write Windows resource objects (.syso) for go build", runSyso},
+	{"inspect", "read version metadata from an ELF, PE or Mach-O binary", runInspect},
+	{"embed", "write version info into an ELF note section of a Linux binary", runEmbed},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout)
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(stderr, "mkversions %s: %v\n", cmd.name, err)
+			return exitUsage
+		case errors.Is(err, mkversions.ErrNoVersionMetadata):
+			fmt.Fprintf(stderr, "mkversions %s: %v\n", cmd.name, err)
+			return exitNoData
+		default:
+			fmt.Fprintf(stderr, "mkversions %s: %v\n", cmd.name, err)
+			return exitError
+		}
+	}
+
+	fmt.Fprintf(stderr, "mkversions: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: mkversions <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// newFlagSet создает набор флагов, ошибки разбора которого возвращаются как errUsage
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("mkversions "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return nil
+}
+
+// infoFlags описывает общие флаги для построения mkversions.Info
+type infoFlags struct {
+	version     string
+	releaseType string
+	developer   string
+	tagPrefix   string
+	fromGit     bool
+	programName string
+	companyName string
+	description string
+	legal       string
+	gitTimeout  time.Duration
+	dir         string
+	module      string
+	native      bool
+}
+
+func (f *infoFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.version, "version", "", "version; derived from git tags when empty")
+	fs.StringVar(&f.releaseType, "release", "", "release type")
+	fs.StringVar(&f.developer, "developer", "", "developer name")
+	fs.StringVar(&f.tagPrefix, "tag-prefix", "v", "prefix of version tags")
+	fs.BoolVar(&f.fromGit, "from-git", false, "derive version from git tags even if -version is set")
+	fs.StringVar(&f.programName, "program", "", "program name")
+	fs.StringVar(&f.companyName, "company", "", "company name")
+	fs.StringVar(&f.description, "description", "", "program description")
+	fs.StringVar(&f.legal, "legal", "", "legal copyright")
+	fs.StringVar(&f.dir, "C", "", "run git in this directory instead of the current one")
+	fs.StringVar(&f.module, "module", "", "module directory relative to the repository root; scopes git data and tags to it")
+	fs.BoolVar(&f.native, "native", false, "read commit, branch and date from .git directly; git is used only for the rest")
+	fs.DurationVar(&f.gitTimeout, "git-timeout", mkversions.DefaultGitTimeout, "timeout of each git command; 0 disables it")
+}
+
+func (f *infoFlags) info(extra ...mkversions.Option) *mkversions.Info {
+	return mkversions.NewInfo(f.version, f.releaseType, f.developer, f.options(extra...)...)
+}
+
+// options возвращает опции mkversions.Info, заданные флагами
+func (f *infoFlags) options(extra ...mkversions.Option) []mkversions.Option {
+	opts := []mkversions.Option{
+		mkversions.WithTagPrefix(f.tagPrefix),
+		mkversions.WithProgramName(f.programName),
+		mkversions.WithCompanyName(f.companyName),
+		mkversions.WithDescription(f.description),
+		mkversions.WithLegal(f.legal),
+		mkversions.WithGitTimeout(f.gitTimeout),
+	}
+	var runner mkversions.GitRunner = &mkversions.ExecGitRunner{Dir: f.dir}
+	if f.native {
+		runner = &mkversions.NativeGitRunner{Dir: f.dir, Fallback: runner}
+	}
+	opts = append(opts, mkversions.WithGitRunner(runner))
+	if f.fromGit {
+		opts = append(opts, mkversions.WithVersionFromGit())
+	}
+	if f.module != "" {
+		opts = append(opts, mkversions.WithModule(f.module))
+	}
+	return append(opts, extra...)
+}
+
+// formatInfo выводит Info в одном из форматов text, json или markdown
+func formatInfo(w io.Writer, info *mkversions.Info, format string) error {
+	switch format {
+	case "text":
+		fmt.Fprintln(w, info.String())
+	case "json":
+		fmt.Fprintln(w, info.JSON())
+	case "markdown", "md":
+		fmt.Fprintln(w, info.ToMarkdown())
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+	return nil
+}
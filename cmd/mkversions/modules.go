@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runModules(args []string, stdout io.Writer) error {
+	fs := newFlagSet("modules")
+	format := fs.String("format", "text", "output format: text or json")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("%w: unknown format %q", errUsage, *format)
+	}
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		var err error
+		dirs, err = mkversions.OpenRepo(flags.dir).Modules(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Info строятся для всех модулей: таблица выводится и при ошибках,
+	// а ошибки возвращаются после нее для вывода в stderr и кода завершения 1
+	infos, infoErr := mkversions.NewModuleInfos(context.Background(), dirs, flags.releaseType, flags.developer, flags.options()...)
+	switch *format {
+	case "text":
+		for i, info := range infos {
+			dirty := ""
+			if info.IsDirty {
+				dirty = " dirty"
+			}
+			fmt.Fprintf(stdout, "%s\t%s\t%s\t%s%s\n", dirs[i], info.Version, info.CommitHashShort, info.CommitDate.Format(time.DateOnly), dirty)
+		}
+	case "json":
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal module info to JSON: %v", err)
+		}
+		fmt.Fprintln(stdout, string(data))
+	}
+	return infoErr
+}
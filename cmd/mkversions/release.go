@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SHEP4RDO/mkversions"
+)
+
+func runRelease(args []string, stdout io.Writer) error {
+	fs := newFlagSet("release")
+	file := fs.String("file", "CHANGELOG.md", "changelog file in Keep a Changelog format")
+	date := fs.String("date", "", "release date as YYYY-MM-DD; today when empty")
+	dryRun := fs.Bool("n", false, "print the updated changelog instead of writing the file")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if flags.version == "" && !flags.fromGit {
+		return fmt.Errorf("%w: -version is required", errUsage)
+	}
+
+	info := flags.info()
+	if *date != "" {
+		d, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			return fmt.Errorf("%w: invalid -date: %v", errUsage, err)
+		}
+		info.SetInfo(mkversions.WithBuildDate(d))
+	}
+
+	if !*dryRun {
+		return info.UpdateChangelogFile(*file)
+	}
+
+	changelog, err := mkversions.ReadKeepAChangelog(*file)
+	if err != nil {
+		return err
+	}
+	if _, err := changelog.AddRelease(info.Version, info.BuildDate, info.Changelog, info.TagPrefix()+info.Version); err != nil {
+		return err
+	}
+	_, err = stdout.Write(changelog.Bytes())
+	return err
+}
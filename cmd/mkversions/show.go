@@ -0,0 +1,17 @@
+package main
+
+import (
+	"io"
+)
+
+func runShow(args []string, stdout io.Writer) error {
+	fs := newFlagSet("show")
+	var flags infoFlags
+	flags.register(fs)
+	format := fs.String("format", "text", "output format: text, json or markdown")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return formatInfo(stdout, flags.info(), *format)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func runSyso(args []string, stdout io.Writer) error {
+	fs := newFlagSet("syso")
+	archs := fs.String("arch", "amd64,386,arm64", "comma-separated target architectures")
+	output := fs.String("o", "rsrc_windows_%s.syso", "output file; %s is replaced by the architecture")
+	icon := fs.String("icon", "", "path to an .ico file")
+	manifest := fs.String("manifest", "", "path to an application manifest")
+	var flags infoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	list := strings.Split(*archs, ",")
+	if len(list) > 1 && !strings.Contains(*output, "%s") {
+		return fmt.Errorf("%w: -o must contain %%s when several architectures are given", errUsage)
+	}
+
+	info := flags.info()
+	info.IconFile = *icon
+	info.ManifestFile = *manifest
+
+	for _, arch := range list {
+		arch = strings.TrimSpace(arch)
+		path := *output
+		if strings.Contains(path, "%s") {
+			path = fmt.Sprintf(path, arch)
+		}
+		if err := info.WriteSyso(path, arch); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "wrote %s\n", path)
+	}
+	return nil
+}
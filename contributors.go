@@ -0,0 +1,150 @@
+package mkversions
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Contributor - статистика участника по коммитам журнала изменений
+type Contributor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// Commits - число коммитов, включая коммиты в соавторстве
+	Commits int `json:"commits"`
+	// CoAuthored - сколько из Commits указаны в трейлерах Co-authored-by
+	CoAuthored  int       `json:"coAuthored,omitempty"`
+	FirstCommit time.Time `json:"firstCommit"`
+	LastCommit  time.Time `json:"lastCommit"`
+	// Additions и Deletions учитываются целиком для каждого участника коммита
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+// Identity - имя и адрес участника
+type Identity struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// Contributors - список участников, отсортированный по числу коммитов
+type Contributors []Contributor
+
+// coAuthorTrailer - ключ трейлера соавтора, регистр не учитывается
+const coAuthorTrailer = "Co-authored-by"
+
+// CoAuthors возвращает соавторов из трейлеров Co-authored-by
+func (c *CommitDetails) CoAuthors() []Identity {
+	var authors []Identity
+	for _, t := range c.Trailers {
+		if !strings.EqualFold(t.Key, coAuthorTrailer) {
+			continue
+		}
+		if name, email, _, ok := parseMailmapIdentity(t.Value); ok {
+			authors = append(authors, Identity{Name: name, Email: email})
+		} else if t.Value != "" {
+			authors = append(authors, Identity{Name: t.Value})
+		}
+	}
+	return authors
+}
+
+// Contributors собирает авторов и соавторов коммитов журнала. Имена и адреса
+// приводятся к каноническим по mailmap (nil - без изменений); участники с одним
+// адресом объединяются. Строки считаются, только если журнал получен со
+// статистикой: Repo.ChangelogStats, Repo.ChangelogRangeStats или WithChangelogStats.
+func (cl *Changelog) Contributors(mailmap *Mailmap) Contributors {
+	index := map[string]int{}
+	var result Contributors
+	credit := func(c *CommitDetails, name, email string, coAuthor bool) {
+		name, email = mailmap.Lookup(name, email)
+		key := strings.ToLower(email)
+		if key == "" {
+			key = "\x00" + strings.ToLower(name)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, Contributor{Name: name, Email: email, FirstCommit: c.AuthorDate, LastCommit: c.AuthorDate})
+		}
+		p := &result[i]
+		p.Commits++
+		if coAuthor {
+			p.CoAuthored++
+		}
+		p.Additions += c.Additions
+		p.Deletions += c.Deletions
+		if c.AuthorDate.Before(p.FirstCommit) {
+			p.FirstCommit = c.AuthorDate
+		}
+		if c.AuthorDate.After(p.LastCommit) {
+			p.LastCommit = c.AuthorDate
+		}
+	}
+
+	for i := range cl.Commits {
+		c := &cl.Commits[i]
+		credit(c, c.Author, c.Email, false)
+		_, authorEmail := mailmap.Lookup(c.Author, c.Email)
+		seen := map[string]bool{strings.ToLower(authorEmail): true}
+		for _, co := range c.CoAuthors() {
+			// Соавтор, совпадающий с автором или указанный дважды, учитывается один раз
+			_, email := mailmap.Lookup(co.Name, co.Email)
+			if email != "" && seen[strings.ToLower(email)] {
+				continue
+			}
+			seen[strings.ToLower(email)] = true
+			credit(c, co.Name, co.Email, true)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		if a.Additions+a.Deletions != b.Additions+b.Deletions {
+			return a.Additions+a.Deletions > b.Additions+b.Deletions
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return result
+}
+
+// summary возвращает описание вклада вида "3 commits, +120/-15"
+func (p *Contributor) summary() string {
+	commits := "commits"
+	if p.Commits == 1 {
+		commits = "commit"
+	}
+	s := fmt.Sprintf("%d %s", p.Commits, commits)
+	if p.CoAuthored > 0 {
+		s += fmt.Sprintf(" (%d co-authored)", p.CoAuthored)
+	}
+	return s + fmt.Sprintf(", +%d/-%d", p.Additions, p.Deletions)
+}
+
+// ToMarkdown выводит раздел "Contributors" для заметок о выпуске
+func (cs Contributors) ToMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString("### Contributors\n\n")
+	for i := range cs {
+		fmt.Fprintf(&sb, "- %s - %s\n", cs[i].Name, cs[i].summary())
+	}
+	return sb.String()
+}
+
+// ToHTML выводит раздел "Contributors" списком HTML
+func (cs Contributors) ToHTML() string {
+	var sb strings.Builder
+	sb.WriteString("<h3>Contributors</h3>\n<ul>\n")
+	for i := range cs {
+		fmt.Fprintf(&sb, "<li>%s - %s</li>\n", html.EscapeString(cs[i].Name), html.EscapeString(cs[i].summary()))
+	}
+	sb.WriteString("</ul>\n")
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package mkversions
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestContributors(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	cl := &Changelog{Commits: []CommitDetails{
+		{
+			Author: "Ann", Email: "ann@example.com", AuthorDate: day(1), Additions: 10, Deletions: 2,
+			Trailers: []Trailer{
+				{"Co-authored-by", "Bob <bob@example.com>"},
+				// Автор и повторный соавтор не учитываются второй раз
+				{"co-authored-by", "Ann <ANN@example.com>"},
+				{"Co-authored-by", "Bob <bob@example.com>"},
+				{"Signed-off-by", "Eve <eve@example.com>"},
+			},
+		},
+		// Адрес в другом регистре - тот же участник
+		{Author: "ann", Email: "Ann@Example.com", AuthorDate: day(3), Additions: 1, Deletions: 1},
+		// Старый адрес приводится к каноническому по mailmap
+		{Author: "Old Bob", Email: "bob-old@example.com", AuthorDate: day(2), Additions: 5},
+		{
+			Author: "Carol", Email: "carol@example.com", AuthorDate: day(4),
+			Trailers: []Trailer{{"Co-authored-by", "Dave"}},
+		},
+	}}
+	mailmap := ParseMailmap([]byte("Bob <bob@example.com> <bob-old@example.com>\n"))
+
+	want := Contributors{
+		{Name: "Bob", Email: "bob@example.com", Commits: 2, CoAuthored: 1, FirstCommit: day(1), LastCommit: day(2), Additions: 15, Deletions: 2},
+		{Name: "Ann", Email: "ann@example.com", Commits: 2, FirstCommit: day(1), LastCommit: day(3), Additions: 11, Deletions: 3},
+		{Name: "Carol", Email: "carol@example.com", Commits: 1, FirstCommit: day(4), LastCommit: day(4)},
+		{Name: "Dave", Commits: 1, CoAuthored: 1, FirstCommit: day(4), LastCommit: day(4)},
+	}
+	got := cl.Contributors(mailmap)
+	if !slices.Equal(got, want) {
+		t.Errorf("Contributors =\n%+v\nwant\n%+v", got, want)
+	}
+
+	wantMarkdown := "### Contributors\n\n" +
+		"- Bob - 2 commits (1 co-authored), +15/-2\n" +
+		"- Ann - 2 commits, +11/-3\n" +
+		"- Carol - 1 commit, +0/-0\n" +
+		"- Dave - 1 commit (1 co-authored), +0/-0\n"
+	if md := got.ToMarkdown(); md != wantMarkdown {
+		t.Errorf("ToMarkdown =\n%s\nwant\n%s", md, wantMarkdown)
+	}
+
+	// Без mailmap старый адрес Bob - отдельный участник
+	if got := cl.Contributors(nil); len(got) != 5 {
+		t.Errorf("Contributors(nil) returned %d contributors, want 5", len(got))
+	}
+}
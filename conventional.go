@@ -0,0 +1,296 @@
+package mkversions
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ConventionalCommit - сообщение коммита, разобранное по Conventional Commits 1.0.0
+type ConventionalCommit struct {
+	Type        string    `json:"type"`
+	Scope       string    `json:"scope,omitempty"`
+	Breaking    bool      `json:"breaking,omitempty"`
+	Description string    `json:"description"`
+	Body        string    `json:"body,omitempty"`
+	Footers     []Trailer `json:"footers,omitempty"`
+	// BreakingNote - текст футера BREAKING CHANGE или описание для "type!:"
+	BreakingNote string `json:"breakingNote,omitempty"`
+}
+
+var (
+	conventionalHeaderRe = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+	conventionalFooterRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE)(?:: | #)(.*)$`)
+)
+
+// ParseConventionalCommit разбирает сообщение коммита на тип, область,
+// признак несовместимого изменения, описание, тело и футеры
+func ParseConventionalCommit(message string) (*ConventionalCommit, error) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	header, rest, _ := strings.Cut(message, "\n")
+	m := conventionalHeaderRe.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return nil, fmt.Errorf("not a conventional commit header: %q", header)
+	}
+
+	cc := &ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       strings.TrimSpace(m[2]),
+		Breaking:    m[3] == "!",
+		Description: strings.TrimSpace(m[4]),
+	}
+	if cc.Breaking {
+		cc.BreakingNote = cc.Description
+	}
+
+	// Футеры - все идущие подряд последние абзацы, начинающиеся с токена,
+	// например BREAKING CHANGE и следом Signed-off-by отдельным абзацем
+	paragraphs := strings.Split(strings.TrimSpace(rest), "\n\n")
+	footerStart := len(paragraphs)
+	for footerStart > 0 {
+		firstLine, _, _ := strings.Cut(paragraphs[footerStart-1], "\n")
+		if !conventionalFooterRe.MatchString(firstLine) {
+			break
+		}
+		footerStart--
+	}
+	for _, paragraph := range paragraphs[footerStart:] {
+		cc.Footers = append(cc.Footers, parseConventionalFooters(paragraph)...)
+	}
+	cc.Body = strings.TrimSpace(strings.Join(paragraphs[:footerStart], "\n\n"))
+
+	for _, f := range cc.Footers {
+		if f.Key == "BREAKING CHANGE" || f.Key == "BREAKING-CHANGE" {
+			cc.Breaking = true
+			cc.BreakingNote = f.Value
+		}
+	}
+	if !cc.Breaking {
+		// BREAKING CHANGE учитывается и вне футеров, в любой строке после заголовка
+		for _, line := range strings.Split(rest, "\n") {
+			note, ok := strings.CutPrefix(line, "BREAKING CHANGE:")
+			if !ok {
+				note, ok = strings.CutPrefix(line, "BREAKING-CHANGE:")
+			}
+			if ok {
+				cc.Breaking = true
+				cc.BreakingNote = strings.TrimSpace(note)
+				break
+			}
+		}
+	}
+	return cc, nil
+}
+
+// parseConventionalFooters разбирает абзац футеров. Строки без
+// токена продолжают значение предыдущего футера.
+func parseConventionalFooters(paragraph string) []Trailer {
+	var footers []Trailer
+	for _, line := range strings.Split(paragraph, "\n") {
+		if m := conventionalFooterRe.FindStringSubmatch(line); m != nil {
+			footers = append(footers, Trailer{Key: m[1], Value: strings.TrimSpace(m[2])})
+			continue
+		}
+		if n := len(footers); n > 0 {
+			footers[n-1].Value += "\n" + line
+		}
+	}
+	return footers
+}
+
+// Bump возвращает тип увеличения версии для коммита
+func (cc *ConventionalCommit) Bump() BumpType {
+	if cc.Breaking {
+		return BumpMajor
+	}
+	switch cc.Type {
+	case "feat":
+		return BumpMinor
+	case "fix":
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// ChangelogGroups задает разделы сгруппированного журнала изменений
+type ChangelogGroups struct {
+	// Titles сопоставляет тип коммита заголовку раздела.
+	// Несколько типов могут вести в один раздел.
+	Titles map[string]string
+	// Order - порядок разделов по заголовкам; не перечисленные разделы идут следом
+	Order []string
+	// BreakingTitle - раздел для несовместимых изменений; пустой - не выделять их
+	BreakingTitle string
+	// OtherTitle - раздел для остальных коммитов; пустой - не показывать их
+	OtherTitle string
+}
+
+// DefaultChangelogGroups возвращает разделы Breaking Changes, Features,
+// Bug Fixes, Performance и Other
+func DefaultChangelogGroups() *ChangelogGroups {
+	return &ChangelogGroups{
+		Titles: map[string]string{
+			"feat": "Features",
+			"fix":  "Bug Fixes",
+			"perf": "Performance",
+		},
+		Order:         []string{"Breaking Changes", "Features", "Bug Fixes", "Performance", "Other"},
+		BreakingTitle: "Breaking Changes",
+		OtherTitle:    "Other",
+	}
+}
+
+// GroupedCommit - коммит журнала вместе с разбором Conventional Commits
+type GroupedCommit struct {
+	CommitDetails
+	Conventional *ConventionalCommit `json:"conventional,omitempty"`
+}
+
+// ChangelogSection - раздел сгруппированного журнала
+type ChangelogSection struct {
+	Title   string          `json:"title"`
+	Commits []GroupedCommit `json:"commits"`
+}
+
+// GroupedChangelog - журнал изменений, разбитый на разделы по типам коммитов
+type GroupedChangelog struct {
+	Sections []ChangelogSection `json:"sections"`
+
+	patterns []ReferencePattern
+}
+
+// Group разбивает коммиты журнала на разделы; groups == nil означает
+// DefaultChangelogGroups
+func (cl *Changelog) Group(groups *ChangelogGroups) *GroupedChangelog {
+	if groups == nil {
+		groups = DefaultChangelogGroups()
+	}
+
+	sections := map[string]*ChangelogSection{}
+	var titles []string
+	add := func(title string, c GroupedCommit) {
+		s, ok := sections[title]
+		if !ok {
+			s = &ChangelogSection{Title: title}
+			sections[title] = s
+			titles = append(titles, title)
+		}
+		s.Commits = append(s.Commits, c)
+	}
+
+	for _, commit := range cl.Commits {
+		c := GroupedCommit{CommitDetails: commit}
+		message := commit.Message
+		if commit.Body != "" {
+			message += "\n\n" + commit.Body
+		}
+		if cc, err := ParseConventionalCommit(message); err == nil {
+			c.Conventional = cc
+		}
+
+		switch title := groups.sectionTitle(c.Conventional); {
+		case title != "":
+			add(title, c)
+		case groups.OtherTitle != "":
+			add(groups.OtherTitle, c)
+		}
+	}
+
+	grouped := &GroupedChangelog{patterns: cl.patterns}
+	seen := map[string]bool{}
+	for _, title := range append(append([]string(nil), groups.Order...), titles...) {
+		if s, ok := sections[title]; ok && !seen[title] {
+			seen[title] = true
+			grouped.Sections = append(grouped.Sections, *s)
+		}
+	}
+	return grouped
+}
+
+func (g *ChangelogGroups) sectionTitle(cc *ConventionalCommit) string {
+	switch {
+	case cc == nil:
+		return ""
+	case cc.Breaking && g.BreakingTitle != "":
+		return g.BreakingTitle
+	}
+	return g.Titles[cc.Type]
+}
+
+// ToMarkdown выводит журнал с заголовком третьего уровня для каждого раздела
+func (gc *GroupedChangelog) ToMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString("## Changelog\n")
+	for _, s := range gc.Sections {
+		fmt.Fprintf(&sb, "\n### %s\n\n", s.Title)
+		for _, c := range s.Commits {
+			sb.WriteString("- ")
+			sb.WriteString(c.markdownLine(gc.patterns))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func (c *GroupedCommit) markdownLine(patterns []ReferencePattern) string {
+	cc := c.Conventional
+	if cc == nil {
+		return fmt.Sprintf("%s (%s)", markdownReferences(c.Message, patterns), c.Hash)
+	}
+
+	var sb strings.Builder
+	if cc.Scope != "" {
+		fmt.Fprintf(&sb, "**%s:** ", cc.Scope)
+	}
+	sb.WriteString(markdownReferences(cc.Description, patterns))
+	fmt.Fprintf(&sb, " (%s)", c.Hash)
+	if cc.Breaking && cc.BreakingNote != cc.Description {
+		note := markdownReferences(cc.BreakingNote, patterns)
+		fmt.Fprintf(&sb, "\n  %s", strings.ReplaceAll(note, "\n", "\n  "))
+	}
+	return sb.String()
+}
+
+// ToHTML выводит журнал с заголовком h3 для каждого раздела
+func (gc *GroupedChangelog) ToHTML() string {
+	var sb strings.Builder
+	sb.WriteString("<h2>Changelog</h2>\n")
+	for _, s := range gc.Sections {
+		fmt.Fprintf(&sb, "<h3>%s</h3>\n<ul>\n", html.EscapeString(s.Title))
+		for _, c := range s.Commits {
+			fmt.Fprintf(&sb, "<li>%s</li>\n", c.htmlLine(gc.patterns))
+		}
+		sb.WriteString("</ul>\n")
+	}
+	return sb.String()
+}
+
+func (c *GroupedCommit) htmlLine(patterns []ReferencePattern) string {
+	cc := c.Conventional
+	if cc == nil {
+		return fmt.Sprintf("%s (<code>%s</code>)", htmlReferences(c.Message, patterns), html.EscapeString(c.Hash))
+	}
+
+	var sb strings.Builder
+	if cc.Scope != "" {
+		fmt.Fprintf(&sb, "<strong>%s:</strong> ", html.EscapeString(cc.Scope))
+	}
+	sb.WriteString(htmlReferences(cc.Description, patterns))
+	fmt.Fprintf(&sb, " (<code>%s</code>)", html.EscapeString(c.Hash))
+	if cc.Breaking && cc.BreakingNote != cc.Description {
+		note := htmlReferences(cc.BreakingNote, patterns)
+		fmt.Fprintf(&sb, "<br>\n%s", strings.ReplaceAll(note, "\n", "<br>\n"))
+	}
+	return sb.String()
+}
+
+// ToJSON выводит разделы журнала в формате JSON
+func (gc *GroupedChangelog) ToJSON() (string, error) {
+	data, err := json.Marshal(gc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal grouped changelog to JSON: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,70 @@
+package mkversions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConventionalCommitBreakingFooter(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		note    string
+		footers []Trailer
+	}{
+		{
+			name:    "footer paragraph",
+			message: "feat: x\n\nBREAKING CHANGE: api gone",
+			note:    "api gone",
+			footers: []Trailer{{Key: "BREAKING CHANGE", Value: "api gone"}},
+		},
+		{
+			name:    "followed by sign-off paragraph",
+			message: "feat: x\n\nBREAKING CHANGE: api gone\n\nSigned-off-by: a <a@b>",
+			note:    "api gone",
+			footers: []Trailer{
+				{Key: "BREAKING CHANGE", Value: "api gone"},
+				{Key: "Signed-off-by", Value: "a <a@b>"},
+			},
+		},
+		{
+			name:    "hyphenated token inside body",
+			message: "fix: y\n\nSome context.\nBREAKING-CHANGE: config renamed\nMore context.\n\nRefs: #12",
+			note:    "config renamed",
+			footers: []Trailer{{Key: "Refs", Value: "#12"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, err := ParseConventionalCommit(tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cc.Breaking || cc.BreakingNote != tt.note {
+				t.Errorf("Breaking = %v, BreakingNote = %q, want true, %q", cc.Breaking, cc.BreakingNote, tt.note)
+			}
+			if !reflect.DeepEqual(cc.Footers, tt.footers) {
+				t.Errorf("Footers = %+v, want %+v", cc.Footers, tt.footers)
+			}
+			if got := ClassifyCommit(tt.message); got != BumpMajor {
+				t.Errorf("ClassifyCommit = %v, want BumpMajor", got)
+			}
+		})
+	}
+}
+
+func TestParseConventionalCommitBody(t *testing.T) {
+	cc, err := ParseConventionalCommit("feat(api): add list\n\nFirst paragraph.\n\nSecond paragraph.\n\nReviewed-by: b <b@c>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.Breaking {
+		t.Error("Breaking = true without a BREAKING CHANGE footer")
+	}
+	if cc.Body != "First paragraph.\n\nSecond paragraph." {
+		t.Errorf("Body = %q", cc.Body)
+	}
+	if len(cc.Footers) != 1 || cc.Footers[0].Key != "Reviewed-by" {
+		t.Errorf("Footers = %+v", cc.Footers)
+	}
+}
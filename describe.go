@@ -0,0 +1,173 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDescribeAttempts ограничивает число попыток пропустить теги, не являющиеся SemVer
+const maxDescribeAttempts = 64
+
+// GitDescribe содержит результат git describe относительно ближайшего SemVer тега
+type GitDescribe struct {
+	Tag        string
+	TagVersion string
+	Distance   int
+	Hash       string
+	Dirty      bool
+}
+
+// String возвращает версию в виде 1.4.2, 1.4.2-3-gabc1234 или 1.4.2-3-gabc1234-dirty
+func (d *GitDescribe) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.TagVersion)
+	if d.Distance > 0 {
+		fmt.Fprintf(&sb, "-%d-g%s", d.Distance, d.Hash)
+	}
+	if d.Dirty {
+		sb.WriteString("-dirty")
+	}
+	return sb.String()
+}
+
+// GetGitDescribe находит ближайший достижимый из HEAD тег вида
+// <tagPrefix><semver> и считает количество коммитов после него.
+// Изменения в путях из exclude не делают версию "-dirty".
+func GetGitDescribe(tagPrefix string, exclude ...string) (*GitDescribe, error) {
+	return GetGitDescribeContext(context.Background(), tagPrefix, exclude...)
+}
+
+// GetGitDescribeContext работает как GetGitDescribe с контекстом
+func GetGitDescribeContext(ctx context.Context, tagPrefix string, exclude ...string) (*GitDescribe, error) {
+	return defaultRepo.Describe(ctx, tagPrefix, exclude...)
+}
+
+// Describe работает как GetGitDescribe для этого репозитория
+func (r *Repo) Describe(ctx context.Context, tagPrefix string, exclude ...string) (*GitDescribe, error) {
+	return r.describe(ctx, tagPrefix, nil, exclude)
+}
+
+// describe находит ближайший тег как Describe. Если заданы paths, Distance
+// и Hash считаются только по коммитам, затрагивающим paths, а "-dirty" -
+// по изменениям в них.
+func (r *Repo) describe(ctx context.Context, tagPrefix string, paths, exclude []string) (*GitDescribe, error) {
+	dirty := len(paths) == 0 && len(exclude) == 0
+	d, err := r.describeRef(ctx, tagPrefix, "", paths, dirty)
+	if err != nil || dirty {
+		return d, err
+	}
+	d.Dirty, err = r.trackedChanges(ctx, paths, exclude)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// describeRef находит ближайший тег, достижимый из ref (по умолчанию HEAD).
+// dirty добавляет проверку рабочей копии git describe --dirty и допустим только для HEAD.
+func (r *Repo) describeRef(ctx context.Context, tagPrefix, ref string, paths []string, dirty bool) (*GitDescribe, error) {
+	var excluded []string
+	for attempt := 0; attempt < maxDescribeAttempts; attempt++ {
+		args := []string{"describe", "--tags", "--long", "--match", tagPrefix + "[0-9]*"}
+		for _, tag := range excluded {
+			args = append(args, "--exclude", tag)
+		}
+		if dirty {
+			args = append(args, "--dirty")
+		} else if ref != "" {
+			args = append(args, ref)
+		}
+
+		stdout, err := r.run(ctx, args...)
+		if err != nil {
+			return nil, wrapGitError(fmt.Sprintf("failed to describe Git %s with prefix %q", refOrHead(ref), tagPrefix), err)
+		}
+
+		d, err := parseGitDescribe(strings.TrimSpace(stdout))
+		if err != nil {
+			return nil, err
+		}
+
+		version := strings.TrimPrefix(d.Tag, tagPrefix)
+		if IsValidSemVer(version) {
+			d.TagVersion = version
+			if len(paths) > 0 {
+				if err := r.describePaths(ctx, d, ref, paths); err != nil {
+					return nil, err
+				}
+			}
+			return d, nil
+		}
+		excluded = append(excluded, d.Tag)
+	}
+	return nil, fmt.Errorf("failed to find semver tag with prefix %q", tagPrefix)
+}
+
+// describePaths пересчитывает Distance и Hash по коммитам после d.Tag до ref,
+// затрагивающим paths
+func (r *Repo) describePaths(ctx context.Context, d *GitDescribe, ref string, paths []string) error {
+	args := append([]string{"rev-list", "--count", d.Tag + ".." + refOrHead(ref), "--"}, paths...)
+	stdout, err := r.run(ctx, args...)
+	if err != nil {
+		return wrapGitError(fmt.Sprintf("failed to count Git commits since %q", d.Tag), err)
+	}
+	if d.Distance, err = strconv.Atoi(strings.TrimSpace(stdout)); err != nil {
+		return fmt.Errorf("failed to parse Git commit count: %v", err)
+	}
+	if d.Distance > 0 {
+		d.Hash, err = r.CommitHashShort(ctx, ref, paths...)
+	}
+	return err
+}
+
+func refOrHead(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+// parseGitDescribe разбирает вывод git describe --long --dirty
+func parseGitDescribe(out string) (*GitDescribe, error) {
+	d := &GitDescribe{}
+	if strings.HasSuffix(out, "-dirty") {
+		d.Dirty = true
+		out = strings.TrimSuffix(out, "-dirty")
+	}
+
+	hashIdx := strings.LastIndex(out, "-g")
+	if hashIdx == -1 {
+		return nil, fmt.Errorf("failed to parse git describe output %q", out)
+	}
+	d.Hash = out[hashIdx+2:]
+	out = out[:hashIdx]
+
+	distIdx := strings.LastIndexByte(out, '-')
+	if distIdx == -1 {
+		return nil, fmt.Errorf("failed to parse git describe output %q", out)
+	}
+	distance, err := strconv.Atoi(out[distIdx+1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse git describe distance: %v", err)
+	}
+	d.Distance = distance
+	d.Tag = out[:distIdx]
+	return d, nil
+}
+
+// trackedChanges сообщает, есть ли изменения отслеживаемых файлов в paths вне exclude
+func (r *Repo) trackedChanges(ctx context.Context, paths, exclude []string) (bool, error) {
+	args := append([]string{"diff", "--quiet", "HEAD"}, pathspec(paths, exclude)...)
+	_, err := r.run(ctx, args...)
+	if err == nil {
+		return false, nil
+	}
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.exitCode() == 1 {
+		return true, nil
+	}
+	return false, wrapGitError("failed to check Git working tree", err)
+}
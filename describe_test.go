@@ -0,0 +1,166 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGitDescribe(t *testing.T) {
+	tests := []struct {
+		out  string
+		want GitDescribe
+	}{
+		{"v1.2.3-0-gabc1234", GitDescribe{Tag: "v1.2.3", Distance: 0, Hash: "abc1234"}},
+		{"v1.2.3-14-gabc1234", GitDescribe{Tag: "v1.2.3", Distance: 14, Hash: "abc1234"}},
+		{"v1.2.3-2-gabc1234-dirty", GitDescribe{Tag: "v1.2.3", Distance: 2, Hash: "abc1234", Dirty: true}},
+		// Дефисы в теге: разбор идет с конца строки
+		{"v2.0.0-rc.1-3-g0123456789ab", GitDescribe{Tag: "v2.0.0-rc.1", Distance: 3, Hash: "0123456789ab"}},
+		{"services/api-gw/v1.0.0-beta-1-gdeadbee-dirty", GitDescribe{Tag: "services/api-gw/v1.0.0-beta", Distance: 1, Hash: "deadbee", Dirty: true}},
+		{"release-2024-01-7-gabc1234", GitDescribe{Tag: "release-2024-01", Distance: 7, Hash: "abc1234"}},
+	}
+	for _, tt := range tests {
+		got, err := parseGitDescribe(tt.out)
+		if err != nil {
+			t.Errorf("parseGitDescribe(%q): %v", tt.out, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseGitDescribe(%q) = %+v, want %+v", tt.out, *got, tt.want)
+		}
+	}
+}
+
+func TestParseGitDescribeInvalid(t *testing.T) {
+	for _, out := range []string{"", "v1.2.3", "v1.2.3-dirty", "abc1234", "v1.2.3-gabc1234", "v1.2.3-x-gabc1234"} {
+		if d, err := parseGitDescribe(out); err == nil {
+			t.Errorf("parseGitDescribe(%q) = %+v, want error", out, d)
+		}
+	}
+}
+
+func TestGitDescribeString(t *testing.T) {
+	tests := []struct {
+		d    GitDescribe
+		want string
+	}{
+		{GitDescribe{TagVersion: "1.2.3", Hash: "abc1234"}, "1.2.3"},
+		{GitDescribe{TagVersion: "1.2.3", Hash: "abc1234", Dirty: true}, "1.2.3-dirty"},
+		{GitDescribe{TagVersion: "1.2.3", Distance: 4, Hash: "abc1234"}, "1.2.3-4-gabc1234"},
+		{GitDescribe{TagVersion: "1.2.3", Distance: 4, Hash: "abc1234", Dirty: true}, "1.2.3-4-gabc1234-dirty"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeRef(t *testing.T) {
+	var calls [][]string
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		calls = append(calls, args)
+		if slices.Contains(args, "v1.x") {
+			return "v1.1.0-5-gdef5678\n", nil
+		}
+		return "v1.x-2-gabc1234\n", nil
+	}))
+
+	d, err := repo.describeRef(context.Background(), "v", "release", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GitDescribe{Tag: "v1.1.0", TagVersion: "1.1.0", Distance: 5, Hash: "def5678"}
+	if *d != want {
+		t.Errorf("describeRef = %+v, want %+v", *d, want)
+	}
+
+	// Тег не по SemVer пропускается повторным вызовом с --exclude
+	wantCalls := [][]string{
+		{"describe", "--tags", "--long", "--match", "v[0-9]*", "release"},
+		{"describe", "--tags", "--long", "--match", "v[0-9]*", "--exclude", "v1.x", "release"},
+	}
+	if !slices.EqualFunc(calls, wantCalls, slices.Equal[[]string]) {
+		t.Errorf("git calls = %q, want %q", calls, wantCalls)
+	}
+}
+
+func TestDescribeRefDirty(t *testing.T) {
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		if args[len(args)-1] != "--dirty" {
+			t.Errorf("git %q has no --dirty", args)
+		}
+		return "v1.0.0-0-gabc1234-dirty\n", nil
+	}))
+
+	d, err := repo.describeRef(context.Background(), "v", "", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.String(); got != "1.0.0-dirty" {
+		t.Errorf("String() = %q, want 1.0.0-dirty", got)
+	}
+}
+
+func TestDescribeRefNoTag(t *testing.T) {
+	gitErr := &GitError{
+		Args:   []string{"describe"},
+		Stderr: "fatal: No names found, cannot describe anything.",
+		Err:    errors.New("exit status 128"),
+	}
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		return "", gitErr
+	}))
+
+	_, err := repo.describeRef(context.Background(), "svc/v", "", nil, false)
+	if !errors.Is(err, gitErr) {
+		t.Fatalf("describeRef error = %v, want wrapped GitError", err)
+	}
+	if !strings.Contains(err.Error(), `prefix "svc/v"`) {
+		t.Errorf("error %q does not mention the tag prefix", err)
+	}
+}
+
+func TestDescribeRefNoSemVerTag(t *testing.T) {
+	calls := 0
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		calls++
+		return "v1.x-0-gabc1234\n", nil
+	}))
+
+	_, err := repo.describeRef(context.Background(), "v", "", nil, false)
+	if err == nil || !strings.Contains(err.Error(), "failed to find semver tag") {
+		t.Errorf("describeRef error = %v, want no semver tag", err)
+	}
+	if calls != maxDescribeAttempts {
+		t.Errorf("git describe ran %d times, want %d", calls, maxDescribeAttempts)
+	}
+}
+
+func TestDescribeRefPaths(t *testing.T) {
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		switch args[0] {
+		case "describe":
+			return "svc/v1.0.0-9-gabc1234\n", nil
+		case "rev-list":
+			if want := []string{"rev-list", "--count", "svc/v1.0.0..HEAD", "--", ":/svc"}; !slices.Equal(args, want) {
+				t.Errorf("git %q, want %q", args, want)
+			}
+			return "2\n", nil
+		case "log":
+			return "fed4321\n", nil
+		}
+		t.Fatalf("unexpected git %q", args)
+		return "", nil
+	}))
+
+	d, err := repo.describeRef(context.Background(), "svc/v", "", []string{":/svc"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.String(); got != "1.0.0-2-gfed4321" {
+		t.Errorf("String() = %q, want 1.0.0-2-gfed4321", got)
+	}
+}
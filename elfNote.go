@@ -0,0 +1,273 @@
+package mkversions
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Типы заметок mkversions, кроме noteTypeInfo. Описания хранятся строками
+// с завершающим NUL. Номера взяты вне диапазонов, которые readelf -n
+// распознает (NT_VERSION, GO BUILDID, OPEN и другие), поэтому readelf -n
+// выводит для них "Unknown note type" и содержимое в шестнадцатеричном виде.
+// Строки читаются командой readelf -p .note.mkversions.
+const (
+	noteTypeVersion = 0x7f000001
+	noteTypeCommit  = 0x7f000002
+	noteTypeBuildID = 0x7f000003
+)
+
+// WriteELFNote записывает Info в секцию .note.mkversions ELF файла path.
+// Секция не загружается в память и не влияет на работу программы; прежняя
+// секция mkversions, если была, заменяется. Версия, коммит и идентификатор
+// сборки видны в readelf -p .note.mkversions, Info целиком - в mkversions inspect.
+func (info *Info) WriteELFNote(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	out, err := info.withELFNote(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, st.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// ReadELFNote читает Info из секции .note.mkversions ELF файла path
+func ReadELFNote(path string) (*Info, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ELF file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	sec := f.Section(elfNoteSection)
+	if sec == nil {
+		return nil, fmt.Errorf("%w: %s has no %s section", ErrNoVersionMetadata, path, elfNoteSection)
+	}
+	data, err := sec.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read section %s: %v", elfNoteSection, err)
+	}
+	info, err := infoFromNotes(data, f.ByteOrder)
+	if err != nil {
+		return nil, err
+	}
+	info.ValidVersion = IsValidSemVer(info.Version)
+	return info, nil
+}
+
+// elfNotes кодирует Info в набор заметок mkversions
+func (info *Info) elfNotes(order binary.ByteOrder) ([]byte, error) {
+	payload, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal version info: %v", err)
+	}
+
+	var b bytes.Buffer
+	b.Write(encodeNote(noteOwner, noteTypeVersion, cString(info.Version), order))
+	if info.GITInfo != nil {
+		b.Write(encodeNote(noteOwner, noteTypeCommit, cString(info.CommitHash), order))
+	}
+	b.Write(encodeNote(noteOwner, noteTypeBuildID, cString(info.BuildID), order))
+	b.Write(encodeNote(noteOwner, noteTypeInfo, payload, order))
+	return b.Bytes(), nil
+}
+
+// cString добавляет NUL, чтобы readelf -p не склеивал строку со следующей заметкой
+func cString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+// encodeNote кодирует одну заметку формата ELF
+func encodeNote(name string, typ uint32, desc []byte, order binary.ByteOrder) []byte {
+	nameBytes := append([]byte(name), 0)
+	out := make([]byte, 12+alignInt(len(nameBytes), 4)+alignInt(len(desc), 4))
+	order.PutUint32(out[0:], uint32(len(nameBytes)))
+	order.PutUint32(out[4:], uint32(len(desc)))
+	order.PutUint32(out[8:], typ)
+	copy(out[12:], nameBytes)
+	copy(out[12+alignInt(len(nameBytes), 4):], desc)
+	return out
+}
+
+// elfSectionHeader - заголовок секции в формате, не зависящем от класса файла
+type elfSectionHeader struct {
+	name, typ              uint32
+	flags, addr, off, size uint64
+	link, info             uint32
+	addralign, entsize     uint64
+}
+
+// withELFNote возвращает образ ELF файла с новой секцией .note.mkversions.
+// Данные секции, новая таблица строк имен секций и таблица заголовков
+// дописываются в конец файла.
+func (info *Info) withELFNote(data []byte) ([]byte, error) {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ELF file: %v", err)
+	}
+	order := f.ByteOrder
+	is64 := f.Class == elf.ELFCLASS64
+
+	var shoffPos, shentsizePos, shnumPos, shstrndxPos int
+	var shoff uint64
+	if is64 {
+		shoffPos, shentsizePos, shnumPos, shstrndxPos = 0x28, 0x3A, 0x3C, 0x3E
+		shoff = order.Uint64(data[shoffPos:])
+	} else {
+		shoffPos, shentsizePos, shnumPos, shstrndxPos = 0x20, 0x2E, 0x30, 0x32
+		shoff = uint64(order.Uint32(data[shoffPos:]))
+	}
+	shentsize := int(order.Uint16(data[shentsizePos:]))
+	shnum := int(order.Uint16(data[shnumPos:]))
+	shstrndx := int(order.Uint16(data[shstrndxPos:]))
+	if shnum == 0 || shnum >= int(elf.SHN_LORESERVE) || shstrndx == int(elf.SHN_UNDEF) || shstrndx >= shnum {
+		return nil, fmt.Errorf("ELF file has no usable section header table")
+	}
+	if shoff+uint64(shnum*shentsize) > uint64(len(data)) {
+		return nil, fmt.Errorf("section header table is out of range")
+	}
+
+	headers := make([]elfSectionHeader, shnum)
+	for i := range headers {
+		headers[i] = readELFSectionHeader(data[shoff+uint64(i*shentsize):], order, is64)
+	}
+
+	strtab := headers[shstrndx]
+	if strtab.off+strtab.size > uint64(len(data)) {
+		return nil, fmt.Errorf("section name table is out of range")
+	}
+	names := append([]byte(nil), data[strtab.off:strtab.off+strtab.size]...)
+
+	// Предыдущая секция mkversions заменяется; если она и служебные таблицы
+	// лежат в конце файла после прошлой записи, хвост отрезается
+	noteIndex := -1
+	for i, s := range f.Sections {
+		if s.Name == elfNoteSection {
+			noteIndex = i
+		}
+	}
+	end := uint64(len(data))
+	if noteIndex != -1 {
+		cut := headers[noteIndex].off
+		tail := true
+		for i, h := range headers {
+			if i == noteIndex || i == shstrndx || h.typ == uint32(elf.SHT_NOBITS) {
+				continue
+			}
+			if h.off+h.size > cut {
+				tail = false
+			}
+		}
+		for _, p := range f.Progs {
+			if p.Off+p.Filesz > cut {
+				tail = false
+			}
+		}
+		if tail && strtab.off >= cut && shoff >= cut {
+			end = cut
+		}
+	}
+
+	notes, err := info.elfNotes(order)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append([]byte(nil), data[:end]...)
+	pad := func(align int) {
+		for len(out)%align != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	pad(4)
+	noteOff := uint64(len(out))
+	out = append(out, notes...)
+
+	if noteIndex == -1 {
+		nameOff := uint32(len(names))
+		names = append(names, append([]byte(elfNoteSection), 0)...)
+		headers = append(headers, elfSectionHeader{name: nameOff, typ: uint32(elf.SHT_NOTE), addralign: 4})
+		noteIndex = len(headers) - 1
+	}
+	headers[noteIndex].off = noteOff
+	headers[noteIndex].size = uint64(len(notes))
+
+	strtabOff := uint64(len(out))
+	out = append(out, names...)
+	headers[shstrndx].off = strtabOff
+	headers[shstrndx].size = uint64(len(names))
+
+	pad(8)
+	newShoff := uint64(len(out))
+	for _, h := range headers {
+		out = append(out, encodeELFSectionHeader(h, order, is64, shentsize)...)
+	}
+
+	if is64 {
+		order.PutUint64(out[shoffPos:], newShoff)
+	} else {
+		order.PutUint32(out[shoffPos:], uint32(newShoff))
+	}
+	order.PutUint16(out[shnumPos:], uint16(len(headers)))
+	return out, nil
+}
+
+func readELFSectionHeader(b []byte, order binary.ByteOrder, is64 bool) elfSectionHeader {
+	if is64 {
+		return elfSectionHeader{
+			name: order.Uint32(b[0:]), typ: order.Uint32(b[4:]),
+			flags: order.Uint64(b[8:]), addr: order.Uint64(b[16:]),
+			off: order.Uint64(b[24:]), size: order.Uint64(b[32:]),
+			link: order.Uint32(b[40:]), info: order.Uint32(b[44:]),
+			addralign: order.Uint64(b[48:]), entsize: order.Uint64(b[56:]),
+		}
+	}
+	return elfSectionHeader{
+		name: order.Uint32(b[0:]), typ: order.Uint32(b[4:]),
+		flags: uint64(order.Uint32(b[8:])), addr: uint64(order.Uint32(b[12:])),
+		off: uint64(order.Uint32(b[16:])), size: uint64(order.Uint32(b[20:])),
+		link: order.Uint32(b[24:]), info: order.Uint32(b[28:]),
+		addralign: uint64(order.Uint32(b[32:])), entsize: uint64(order.Uint32(b[36:])),
+	}
+}
+
+func encodeELFSectionHeader(h elfSectionHeader, order binary.ByteOrder, is64 bool, size int) []byte {
+	b := make([]byte, size)
+	order.PutUint32(b[0:], h.name)
+	order.PutUint32(b[4:], h.typ)
+	if is64 {
+		order.PutUint64(b[8:], h.flags)
+		order.PutUint64(b[16:], h.addr)
+		order.PutUint64(b[24:], h.off)
+		order.PutUint64(b[32:], h.size)
+		order.PutUint32(b[40:], h.link)
+		order.PutUint32(b[44:], h.info)
+		order.PutUint64(b[48:], h.addralign)
+		order.PutUint64(b[56:], h.entsize)
+		return b
+	}
+	order.PutUint32(b[8:], uint32(h.flags))
+	order.PutUint32(b[12:], uint32(h.addr))
+	order.PutUint32(b[16:], uint32(h.off))
+	order.PutUint32(b[20:], uint32(h.size))
+	order.PutUint32(b[24:], h.link)
+	order.PutUint32(b[28:], h.info)
+	order.PutUint32(b[32:], uint32(h.addralign))
+	order.PutUint32(b[36:], uint32(h.entsize))
+	return b
+}
@@ -0,0 +1,144 @@
+package mkversions
+
+import (
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testELFFile копирует исполняемый файл теста, который на Linux является ELF
+func testELFFile(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("test binary is not an ELF file")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testELFInfo() *Info {
+	return &Info{
+		Version: "1.2.3",
+		BuildID: "0123456789abcdef",
+		GITInfo: &GITInfo{CommitHash: "b06f67773546e9c20d35d90f7f5fc73b5fe22c8f"},
+	}
+}
+
+func TestWriteELFNote(t *testing.T) {
+	path := testELFFile(t)
+	if err := testELFInfo().WriteELFNote(path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := elf.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sec := f.Section(elfNoteSection)
+	if sec == nil {
+		t.Fatalf("no %s section", elfNoteSection)
+	}
+	if sec.Type != elf.SHT_NOTE {
+		t.Errorf("section type = %v, want SHT_NOTE", sec.Type)
+	}
+	data, err := sec.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[uint32]string{
+		noteTypeVersion: "1.2.3\x00",
+		noteTypeCommit:  "b06f67773546e9c20d35d90f7f5fc73b5fe22c8f\x00",
+		noteTypeBuildID: "0123456789abcdef\x00",
+	}
+	notes := parseNotes(data, f.ByteOrder)
+	if len(notes) != 4 {
+		t.Fatalf("got %d notes, want 4", len(notes))
+	}
+	for _, n := range notes {
+		if n.name != noteOwner {
+			t.Errorf("note owner = %q, want %q", n.name, noteOwner)
+		}
+		if s, ok := want[n.typ]; ok && string(n.desc) != s {
+			t.Errorf("note %#x = %q, want %q", n.typ, n.desc, s)
+		}
+	}
+
+	info, err := ReadELFNote(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "1.2.3" || info.CommitHash != testELFInfo().CommitHash {
+		t.Errorf("ReadELFNote = %q, %q", info.Version, info.CommitHash)
+	}
+}
+
+// TestWriteELFNoteReadelf проверяет, что пишет о заметках readelf из binutils
+func TestWriteELFNoteReadelf(t *testing.T) {
+	readelf, err := exec.LookPath("readelf")
+	if err != nil {
+		t.Skip("readelf is not installed")
+	}
+	path := testELFFile(t)
+	if err := testELFInfo().WriteELFNote(path); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(readelf, "-n", path).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, notes, _ := strings.Cut(string(out), "Displaying notes found in: "+elfNoteSection)
+	if notes == "" {
+		t.Fatalf("readelf -n does not list %s:\n%s", elfNoteSection, out)
+	}
+	notes, _, _ = strings.Cut(notes, "Displaying notes found in:")
+	if n := strings.Count(notes, noteOwner); n != 4 {
+		t.Errorf("readelf -n lists %d mkversions notes, want 4:\n%s", n, notes)
+	}
+	// Заметки не должны выдавать себя за стандартные
+	for _, label := range []string{"NT_VERSION", "GO BUILDID", "OPEN", "func"} {
+		if strings.Contains(notes, label) {
+			t.Errorf("readelf -n labels a mkversions note as %s:\n%s", label, notes)
+		}
+	}
+	if n := strings.Count(notes, "Unknown note type"); n != 4 {
+		t.Errorf("readelf -n shows %d unknown note types, want 4:\n%s", n, notes)
+	}
+
+	out, err = exec.Command(readelf, "-p", elfNoteSection, path).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stringsDump []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if _, s, ok := strings.Cut(line, "]  "); ok {
+			stringsDump = append(stringsDump, s)
+		}
+	}
+	for _, s := range []string{"1.2.3", testELFInfo().CommitHash, "0123456789abcdef"} {
+		found := false
+		for _, got := range stringsDump {
+			found = found || got == s
+		}
+		if !found {
+			t.Errorf("readelf -p does not print %q as a separate string:\n%s", s, out)
+		}
+	}
+}
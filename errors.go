@@ -0,0 +1,66 @@
+package mkversions
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Типизированные причины ошибок git; проверяются через errors.Is
+var (
+	ErrGitNotFound    = errors.New("git executable not found")
+	ErrNotARepository = errors.New("not a git repository")
+	ErrDetachedHead   = errors.New("git HEAD is detached")
+	ErrNoCommits      = errors.New("git repository has no commits")
+)
+
+// GitError описывает неудачный запуск git: точные аргументы и вывод stderr.
+// errors.Is для нее находит как Err, так и причины ErrGitNotFound,
+// ErrNotARepository и ErrNoCommits.
+type GitError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *GitError) Error() string {
+	msg := fmt.Sprintf("git %s: %v", strings.Join(e.Args, " "), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *GitError) Unwrap() []error {
+	switch {
+	case errors.Is(e.Err, exec.ErrNotFound):
+		return []error{ErrGitNotFound, e.Err}
+	case strings.Contains(e.Stderr, "not a git repository"):
+		return []error{ErrNotARepository, e.Err}
+	case strings.Contains(e.Stderr, "does not have any commits yet"),
+		strings.Contains(e.Stderr, "malformed object name HEAD"),
+		strings.Contains(e.Stderr, "bad revision 'HEAD'"):
+		return []error{ErrNoCommits, e.Err}
+	}
+	return []error{e.Err}
+}
+
+// exitCode возвращает код завершения git или -1, если git не был запущен
+func (e *GitError) exitCode() int {
+	var exitErr *exec.ExitError
+	if errors.As(e.Err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// wrapGitError дополняет ошибку запуска git описанием операции
+func wrapGitError(msg string, err error) error {
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// addWarning записывает, что поле получило значение по умолчанию и почему
+func (info *Info) addWarning(field string, err error) {
+	info.Warnings = append(info.Warnings, fmt.Sprintf("%s: %v", field, err))
+}
@@ -3,11 +3,9 @@ package mkversions
 import (
 	"fmt"
 	"io"
-	"net/http"
+	"log/slog"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"runtime"
 	"time"
 )
 
@@ -19,48 +17,9 @@ type AppMetadata struct {
 	Legal          string
 	CompanyName    string
 	InternalName   string
-}
-
-const (
-	rceditURL64 = "https://github.com/electron/rcedit/releases/download/v1.1.1/rcedit-x64.exe"
-	rceditURL86 = "https://github.com/electron/rcedit/releases/download/v1.1.1/rcedit-x86.exe"
-)
-
-func downloadRcedit() (string, error, bool) {
-	// Определяем URL в зависимости от архитектуры системы
-	url := rceditURL64
-	if runtime.GOARCH == "386" {
-		url = rceditURL86
-	}
-
-	// Определяем путь для сохранения rcedit
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %v", err), false
-	}
-	rceditPath := filepath.Join(homeDir, "rcedit.exe")
-
-	// Загружаем файл
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download rcedit: %v", err), false
-	}
-	defer resp.Body.Close()
-
-	// Создаем файл для сохранения
-	out, err := os.Create(rceditPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create rcedit file: %v", err), false
-	}
-	defer out.Close()
-
-	// Копируем содержимое ответа в файл
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to save rcedit file: %v", err), false
-	}
-
-	return rceditPath, nil, true
+	Translations   []VersionTranslation
+	IconFile       string
+	ManifestFile   string
 }
 
 // copyFile копирует файл из source в destination
@@ -81,25 +40,18 @@ func copyFile(source, destination string) error {
 	return err
 }
 
-// UpdateExeMetadata обновляет метаданные в указанном .exe файле
+// UpdateExeMetadata обновляет метаданные в указанном .exe файле.
+//
+// Deprecated: ресурсы записываются без rcedit, аргумент rcedit игнорируется.
+// Используйте WriteExeMetadata.
 func (i *Info) UpdateExeMetadata(rcedit, exePath string) error {
-	cmd := exec.Command(rcedit, exePath,
-		"--set-file-version", i.Version,
-		"--set-product-version", i.ProductVersion,
-		"--set-version-string", "ProductName", i.ProgramName,
-		"--set-version-string", "FileDescription", i.Description,
-		"--set-version-string", "LegalCopyright", i.Legal,
-		"--set-version-string", "CompanyName", i.CompanyName,
-		"--set-version-string", "InternalName", i.ProgramName,
-	)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to update exe metadata: %v, output: %s", err, string(output))
+	if err := i.WriteExeMetadata(exePath); err != nil {
+		return fmt.Errorf("failed to update exe metadata: %v", err)
 	}
 	return nil
 }
 
-func (i *Info) performUpdate(rcedit string) error {
+func (i *Info) performUpdate() error {
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %v", err)
@@ -116,7 +68,7 @@ func (i *Info) performUpdate(rcedit string) error {
 		return fmt.Errorf("failed to copy file to %s: %v", tempPath, err)
 	}
 
-	if err := i.UpdateExeMetadata(rcedit, tempPath); err != nil {
+	if err := i.WriteExeMetadata(tempPath); err != nil {
 		os.Remove(tempPath)
 		return fmt.Errorf("failed to update metadata: %v", err)
 	}
@@ -131,13 +83,14 @@ func (i *Info) performUpdate(rcedit string) error {
 		return fmt.Errorf("failed to replace original file: %v", err)
 	}
 
+	logger := i.log()
 	if file, err := os.Create(removeSignalPath); err == nil {
 		file.Close()
 	} else {
-		fmt.Printf("Warning: Failed to create remove signal file: %v\n", err)
+		logger.Warn("failed to create remove signal file", slog.String("path", removeSignalPath), slog.Any("error", err))
 	}
 
-	fmt.Println("Update successful! Restarting...")
+	logger.Info("update successful, restarting", slog.String("path", exePath))
 	time.Sleep(1 * time.Second)
 
 	// Перезапускаем программу
@@ -148,15 +101,13 @@ func (i *Info) performUpdate(rcedit string) error {
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to restart program: %v", err)
 	}
-
-	os.Exit(0)
 	return nil
 }
 
-func handleRemoveSignal() {
+func handleRemoveSignal(logger *slog.Logger) {
 	exePath, err := os.Executable()
 	if err != nil {
-		fmt.Printf("Failed to get executable path: %v\n", err)
+		logger.Error("failed to get executable path", slog.Any("error", err))
 		return
 	}
 
@@ -164,28 +115,28 @@ func handleRemoveSignal() {
 	backupPath := exePath + ".bak"
 
 	if _, err := os.Stat(removeSignalPath); err == nil {
-		fmt.Println("Remove signal detected")
+		logger.Info("remove signal detected", slog.String("path", removeSignalPath))
 
 		// Попытка удалить файл .bak
 		err := removeFileWithRetry(backupPath)
 		if err != nil {
-			fmt.Printf("Failed to remove backup file: %v\n", err)
+			logger.Warn("failed to remove backup file", slog.String("path", backupPath), slog.Any("error", err))
 			// Если не удалось удалить, попробуем переименовать
 			newBackupPath := backupPath + ".old"
 			if renameErr := os.Rename(backupPath, newBackupPath); renameErr != nil {
-				fmt.Printf("Failed to rename backup file: %v\n", renameErr)
+				logger.Error("failed to rename backup file", slog.String("path", backupPath), slog.Any("error", renameErr))
 			} else {
-				fmt.Printf("Backup file renamed to %s\n", newBackupPath)
+				logger.Info("backup file renamed", slog.String("path", newBackupPath))
 			}
 		} else {
-			fmt.Println("Backup file removed successfully")
+			logger.Info("backup file removed", slog.String("path", backupPath))
 		}
 
 		// Удаляем файл сигнала
 		if err := os.Remove(removeSignalPath); err != nil {
-			fmt.Printf("Failed to remove signal file: %v\n", err)
+			logger.Warn("failed to remove signal file", slog.String("path", removeSignalPath), slog.Any("error", err))
 		} else {
-			fmt.Println("Signal file removed successfully")
+			logger.Info("signal file removed", slog.String("path", removeSignalPath))
 		}
 	}
 }
@@ -209,76 +160,31 @@ func removeFileWithRetry(filePath string) error {
 
 	return err
 }
-func (i *Info) RunUpdate(rceditPath ...string) {
-	handleRemoveSignal()
 
-	var path string
-	if len(rceditPath) > 0 {
-		path = rceditPath[0]
-	}
-
-	localPaths := []string{
-		"./rcedit.exe",
-		"./rcedit-x64.exe",
-		"./rcedit-x86.exe",
-		"/usr/local/bin/rcedit-x64",
-		"/usr/local/bin/rcedit-x86",
-		"C:\\Program Files\\rcedit.exe",
-		"C:\\Program Files\\rcedit-x64.exe",
-		"C:\\Program Files (x86)\\rcedit-x86.exe",
-	}
+// RunUpdate обрабатывает аргументы --update и --tmp-clear: записывает
+// метаданные в исполняемый файл и перезапускает программу.
+// exit сообщает, что вызывающая программа должна завершиться: с ненулевым
+// кодом, если err != nil. Аргумент rceditPath сохранен для совместимости и игнорируется.
+func (i *Info) RunUpdate(rceditPath ...string) (exit bool, err error) {
+	logger := i.log()
+	handleRemoveSignal(logger)
 
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "--tmp-clear":
-			handleRemoveSignal()
-			fmt.Println("Temporary files cleared. Exiting...")
-			os.Exit(0)
+			handleRemoveSignal(logger)
+			logger.Info("temporary files cleared")
+			return true, nil
 
 		case "--update":
-			var err error
-			downloadedRcedit := false
-
-			if path == "" {
-				fmt.Println("No rcedit path provided. Searching in local directories and standard paths...")
-				for _, p := range localPaths {
-					if fileExists(p) {
-						path = p
-						break
-					}
-				}
-
-				if path == "" {
-					fmt.Println("rcedit not found. Attempting to download...")
-					path, err, downloadedRcedit = downloadRcedit()
-					if err != nil {
-						fmt.Printf("Failed to download rcedit: %v\n", err)
-						os.Exit(1)
-					}
-				}
-			} else if !fileExists(path) {
-				fmt.Printf("rcedit not found at %s.\n", path)
-				os.Exit(1)
-			}
-
-			if err = i.performUpdate(path); err != nil {
-				fmt.Printf("Update failed: %v\n", err)
-				os.Exit(1)
-			}
-
-			if downloadedRcedit {
-				if err := os.Remove(path); err != nil {
-					fmt.Printf("Warning: Failed to remove downloaded rcedit: %v\n", err)
-				}
+			if err := i.performUpdate(); err != nil {
+				logger.Error("update failed", slog.Any("error", err))
+				return true, err
 			}
+			return true, nil
 		default:
-			fmt.Println("Usage: --update or --tmp-clear")
-			os.Exit(1)
+			return true, fmt.Errorf("unknown argument %q: expected --update or --tmp-clear", os.Args[1])
 		}
 	}
-}
-
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return !os.IsNotExist(err)
+	return false, nil
 }
@@ -0,0 +1,154 @@
+package mkversions
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"time"
+)
+
+// GenerateGoFile записывает в path Go-файл пакета pkgName с константами версии
+// и заполненным значением VersionInfo. Результат детерминирован: одинаковый Info
+// дает побайтно одинаковый файл, а неизменный файл не перезаписывается.
+//
+// Пример использования с go generate:
+//
+//	//go:generate go run github.com/SHEP4RDO/mkversions/cmd/mkversions generate -o version_gen.go
+func (info *Info) GenerateGoFile(pkgName, path string) error {
+	src, err := info.GoSource(pkgName)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, src) {
+		return nil
+	}
+
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		return fmt.Errorf("failed to write generated file %s: %v", path, err)
+	}
+	return nil
+}
+
+// GoSource возвращает отформатированный исходный код, который записывает GenerateGoFile
+func (info *Info) GoSource(pkgName string) ([]byte, error) {
+	if pkgName == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+
+	git := info.GITInfo
+	if git == nil {
+		git = &GITInfo{}
+	}
+	meta := info.AppMetadata
+	if meta == nil {
+		meta = &AppMetadata{}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by mkversions. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	fmt.Fprintf(&b, "import (\n\"time\"\n\n%q\n)\n\n", PackagePath)
+
+	fmt.Fprintf(&b, "const (\n")
+	fmt.Fprintf(&b, "Version string = %q\n", info.Version)
+	fmt.Fprintf(&b, "Commit string = %q\n", git.CommitHash)
+	fmt.Fprintf(&b, "CommitShort string = %q\n", git.CommitHashShort)
+	fmt.Fprintf(&b, "Branch string = %q\n", git.BranchName)
+	fmt.Fprintf(&b, "Dirty bool = %t\n", git.IsDirty)
+	fmt.Fprintf(&b, "BuildDate string = %q\n", formatGoTime(info.BuildDate))
+	fmt.Fprintf(&b, "BuildID string = %q\n", info.BuildID)
+	fmt.Fprintf(&b, "ReleaseType string = %q\n", info.ReleaseType)
+	fmt.Fprintf(&b, "Developer string = %q\n", info.Developer)
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, "// VersionInfo содержит информацию о версии, зафиксированную при генерации\n")
+	fmt.Fprintf(&b, "var VersionInfo = &mkversions.Info{\n")
+	fmt.Fprintf(&b, "Version: Version,\n")
+	fmt.Fprintf(&b, "BuildDate: %s,\n", goTimeLiteral(info.BuildDate))
+	fmt.Fprintf(&b, "GoVersion: %q,\n", info.GoVersion)
+	fmt.Fprintf(&b, "Platform: %q,\n", info.Platform)
+	fmt.Fprintf(&b, "BuildID: BuildID,\n")
+	fmt.Fprintf(&b, "ReleaseType: ReleaseType,\n")
+	fmt.Fprintf(&b, "Architecture: %q,\n", info.Architecture)
+	fmt.Fprintf(&b, "Developer: Developer,\n")
+	writeGoStringMap(&b, "Dependencies", info.Dependencies)
+	writeGoStringMap(&b, "DependencySums", info.DependencySums)
+	writeGoStringMap(&b, "BuildSettings", info.BuildSettings)
+	fmt.Fprintf(&b, "DetailedVersion: %q,\n", info.DetailedVersion)
+	fmt.Fprintf(&b, "ValidVersion: %t,\n", info.ValidVersion)
+
+	fmt.Fprintf(&b, "GITInfo: &mkversions.GITInfo{\n")
+	fmt.Fprintf(&b, "CommitHash: Commit,\n")
+	fmt.Fprintf(&b, "CommitHashShort: CommitShort,\n")
+	fmt.Fprintf(&b, "BranchName: Branch,\n")
+	fmt.Fprintf(&b, "CommitDate: %s,\n", goTimeLiteral(git.CommitDate))
+	fmt.Fprintf(&b, "IsDirty: Dirty,\n")
+	writeGoStringSlice(&b, "ModifiedFiles", git.ModifiedFiles)
+	writeGoStringSlice(&b, "UntrackedFiles", git.UntrackedFiles)
+	fmt.Fprintf(&b, "DiffHash: %q,\n", git.DiffHash)
+	fmt.Fprintf(&b, "},\n")
+
+	fmt.Fprintf(&b, "AppMetadata: &mkversions.AppMetadata{\n")
+	fmt.Fprintf(&b, "ProductVersion: %q,\n", meta.ProductVersion)
+	fmt.Fprintf(&b, "ProgramName: %q,\n", meta.ProgramName)
+	fmt.Fprintf(&b, "Description: %q,\n", meta.Description)
+	fmt.Fprintf(&b, "Legal: %q,\n", meta.Legal)
+	fmt.Fprintf(&b, "CompanyName: %q,\n", meta.CompanyName)
+	fmt.Fprintf(&b, "InternalName: %q,\n", meta.InternalName)
+	fmt.Fprintf(&b, "},\n")
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %v", err)
+	}
+	return src, nil
+}
+
+func formatGoTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// goTimeLiteral возвращает выражение Go, восстанавливающее момент времени t в UTC
+func goTimeLiteral(t time.Time) string {
+	if t.IsZero() {
+		return "time.Time{}"
+	}
+	return fmt.Sprintf("time.Unix(%d, %d).UTC()", t.Unix(), t.Nanosecond())
+}
+
+func writeGoStringMap(b *bytes.Buffer, field string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(b, "%s: map[string]string{\n", field)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%q: %q,\n", k, m[k])
+	}
+	fmt.Fprintf(b, "},\n")
+}
+
+func writeGoStringSlice(b *bytes.Buffer, field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "%s: []string{", field)
+	for _, v := range values {
+		fmt.Fprintf(b, "%q, ", v)
+	}
+	fmt.Fprintf(b, "},\n")
+}
@@ -0,0 +1,105 @@
+package mkversions
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultGitTimeout ограничивает время выполнения одной команды git,
+// если в контексте не задан другой тайм-аут. 0 отключает ограничение.
+var DefaultGitTimeout = 30 * time.Second
+
+type gitTimeoutKey struct{}
+
+// ContextWithGitTimeout задает тайм-аут для каждой команды git, запущенной
+// с этим контекстом. d <= 0 отключает ограничение.
+func ContextWithGitTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, gitTimeoutKey{}, d)
+}
+
+func gitTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(gitTimeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return DefaultGitTimeout
+}
+
+// GitRunner выполняет команды git и возвращает их stdout
+type GitRunner interface {
+	Run(ctx context.Context, args ...string) (string, error)
+}
+
+// ExecGitRunner запускает исполняемый файл git.
+// Пустые поля означают текущий каталог, git из PATH и окружение процесса.
+type ExecGitRunner struct {
+	Dir     string
+	GitPath string
+	// Env добавляется к окружению процесса; LC_ALL и LANGUAGE всегда
+	// переопределяются, чтобы сообщения git не переводились
+	Env []string
+}
+
+// gitLocaleEnv отключает перевод сообщений git
+var gitLocaleEnv = []string{"LC_ALL=C", "LANGUAGE="}
+
+// Run запускает git с аргументами args. Ошибка всегда имеет тип *GitError.
+func (e *ExecGitRunner) Run(ctx context.Context, args ...string) (string, error) {
+	gitPath := e.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = e.Dir
+	// Ошибки классифицируются по тексту stderr, поэтому сообщения git
+	// должны быть на английском независимо от локали пользователя
+	cmd.Env = append(append(cmd.Environ(), e.Env...), gitLocaleEnv...)
+	// Не ждем бесконечно потомков git, унаследовавших stdout
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return stdout.String(), &GitError{Args: args, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+	}
+	return stdout.String(), nil
+}
+
+// Repo читает метаданные репозитория git через GitRunner
+type Repo struct {
+	runner GitRunner
+}
+
+// defaultRepo используется пакетными функциями GetGit*: git из PATH в текущем каталоге
+var defaultRepo = NewRepo(nil)
+
+// NewRepo создает Repo поверх runner; nil означает ExecGitRunner по умолчанию
+func NewRepo(runner GitRunner) *Repo {
+	if runner == nil {
+		runner = &ExecGitRunner{}
+	}
+	return &Repo{runner: runner}
+}
+
+// OpenRepo создает Repo для рабочей копии в каталоге dir
+func OpenRepo(dir string) *Repo {
+	return NewRepo(&ExecGitRunner{Dir: dir})
+}
+
+// run выполняет команду git с тайм-аутом из ctx
+func (r *Repo) run(ctx context.Context, args ...string) (string, error) {
+	if d := gitTimeout(ctx); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	return r.runner.Run(ctx, args...)
+}
@@ -0,0 +1,307 @@
+package mkversions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitMetadata - состояние HEAD и рабочей копии, собранное за один проход
+type GitMetadata struct {
+	CommitHash string
+	// BranchName равно "HEAD", если HEAD отсоединен
+	BranchName string
+	Detached   bool
+	CommitDate time.Time
+	AuthorDate time.Time
+	// Tags - теги, указывающие на HEAD
+	Tags []string
+	WorkTreeStatus
+}
+
+// GetGitMetadata собирает хэш, ветку, даты, теги и состояние рабочей копии
+func GetGitMetadata(exclude ...string) (*GitMetadata, error) {
+	return GetGitMetadataContext(context.Background(), exclude...)
+}
+
+// GetGitMetadataContext работает как GetGitMetadata с контекстом
+func GetGitMetadataContext(ctx context.Context, exclude ...string) (*GitMetadata, error) {
+	return defaultRepo.Metadata(ctx, exclude...)
+}
+
+// Metadata собирает состояние репозитория двумя вызовами git: status и log -1.
+// Данные коммита HEAD кэшируются по состоянию HEAD и ссылок, поэтому повторные
+// вызовы запускают только git status: состояние рабочей копии не кэшируется.
+func (r *Repo) Metadata(ctx context.Context, exclude ...string) (*GitMetadata, error) {
+	return r.metadata(ctx, r.stateCache(), nil, exclude)
+}
+
+// metadata работает как Metadata; состояние рабочей копии собирается только в paths
+func (r *Repo) metadata(ctx context.Context, cache *gitStateCache, paths, exclude []string) (*GitMetadata, error) {
+	var meta *GitMetadata
+	var err error
+	if rr, ok := r.runner.(refReader); ok {
+		meta, err = cache.head(func() (*GitMetadata, error) { return rr.headMetadata(ctx) })
+		if err == nil {
+			var status *WorkTreeStatus
+			status, err = r.workTreeStatus(ctx, paths, exclude)
+			if err == nil {
+				meta.WorkTreeStatus = *status
+			}
+		}
+	} else {
+		meta, err = r.execMetadata(ctx, cache, paths, exclude)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (r *Repo) execMetadata(ctx context.Context, cache *gitStateCache, paths, exclude []string) (*GitMetadata, error) {
+	args := append([]string{"status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all"}, pathspec(paths, exclude)...)
+	stdout, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, wrapGitError("failed to get Git status", err)
+	}
+	status := parsePorcelainStatus(stdout)
+
+	hash := status.Headers["branch.oid"]
+	if hash == "(initial)" {
+		return nil, ErrNoCommits
+	}
+	meta, err := cache.head(func() (*GitMetadata, error) {
+		head := &GitMetadata{CommitHash: hash, BranchName: status.Headers["branch.head"]}
+		if head.BranchName == "(detached)" {
+			head.BranchName = "HEAD"
+			head.Detached = true
+		}
+		return head, r.commitMetadata(ctx, head)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta.WorkTreeStatus = status.WorkTreeStatus
+	if err := r.fillDiffHash(ctx, &meta.WorkTreeStatus, paths, exclude); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// commitMetadata заполняет даты и теги коммита meta.CommitHash
+func (r *Repo) commitMetadata(ctx context.Context, meta *GitMetadata) error {
+	stdout, err := r.run(ctx, "log", "-1", "--format=%H%x00%ad%x00%cd%x00%D", "--date=raw", meta.CommitHash)
+	if err != nil {
+		return wrapGitError("failed to get Git commit", err)
+	}
+	fields := strings.Split(strings.TrimRight(stdout, "\n"), "\x00")
+	if len(fields) != 4 {
+		return fmt.Errorf("failed to parse Git commit %q", stdout)
+	}
+	if meta.AuthorDate, err = parseRawGitDate(fields[1]); err != nil {
+		return err
+	}
+	if meta.CommitDate, err = parseRawGitDate(fields[2]); err != nil {
+		return err
+	}
+	meta.Tags = decorationTags(fields[3])
+	return nil
+}
+
+// decorationTags выделяет отсортированные теги из вывода %D
+func decorationTags(refs string) []string {
+	var tags []string
+	for _, ref := range strings.Split(refs, ", ") {
+		if tag, ok := strings.CutPrefix(ref, "tag: "); ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// headMetadata читает HEAD, даты коммита и теги из .git
+func (n *NativeGitRunner) headMetadata(ctx context.Context) (*GitMetadata, error) {
+	repo, err := n.open(ctx)
+	if err != nil {
+		return nil, wrapGitError("failed to get Git commit", err)
+	}
+
+	meta := &GitMetadata{}
+	if meta.BranchName, err = repo.branchName(); err != nil {
+		return nil, wrapGitError("failed to get Git branch name", err)
+	}
+	meta.Detached = meta.BranchName == "HEAD"
+	if meta.CommitHash, err = repo.resolve("HEAD"); err != nil {
+		return nil, wrapGitError("failed to get Git commit hash", err)
+	}
+	commit, err := repo.readCommit(meta.CommitHash)
+	if err != nil {
+		return nil, wrapGitError("failed to get Git commit", err)
+	}
+	meta.AuthorDate, meta.CommitDate = commit.authorDate, commit.commitDate
+	if meta.Tags, err = repo.tagsAt(meta.CommitHash); err != nil {
+		return nil, wrapGitError("failed to list Git tags", err)
+	}
+	return meta, nil
+}
+
+// parseRawGitDate разбирает дату в формате --date=raw
+func parseRawGitDate(s string) (time.Time, error) {
+	seconds, zone, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return time.Time{}, fmt.Errorf("failed to parse Git date %q", s)
+	}
+	return parseGitTime(seconds, zone)
+}
+
+func (m *GitMetadata) clone() *GitMetadata {
+	c := *m
+	c.Tags = append([]string(nil), m.Tags...)
+	c.ModifiedFiles = append([]string(nil), m.ModifiedFiles...)
+	c.UntrackedFiles = append([]string(nil), m.UntrackedFiles...)
+	return &c
+}
+
+// stateCache возвращает кэш для текущего состояния HEAD и ссылок репозитория.
+// Кэш доступен только для известных GitRunner; иначе возвращается nil,
+// и все запросы выполняются без кэширования.
+func (r *Repo) stateCache() *gitStateCache {
+	var dir, runner string
+	switch g := r.runner.(type) {
+	case *ExecGitRunner:
+		dir, runner = g.Dir, g.cacheKey()
+	case *NativeGitRunner:
+		dir, runner = g.Dir, "native"
+		if g.Fallback != nil {
+			fallback, ok := g.Fallback.(*ExecGitRunner)
+			if !ok || fallback.Dir != g.Dir {
+				return nil
+			}
+			runner += ":" + fallback.cacheKey()
+		}
+	default:
+		return nil
+	}
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	repo, err := openNativeRepo(abs)
+	if err != nil {
+		return nil
+	}
+	head, ok, err := repo.readRef("HEAD")
+	if err != nil || !ok {
+		return nil
+	}
+	hash, err := repo.resolve("HEAD")
+	if err != nil {
+		return nil
+	}
+	refs, err := repo.refsFingerprint()
+	if err != nil {
+		return nil
+	}
+
+	repoKey := runner + "\x01" + abs
+	state := strings.Join([]string{repo.gitDir, head, hash, refs}, "\x01")
+
+	gitCaches.mu.Lock()
+	defer gitCaches.mu.Unlock()
+	c, ok := gitCaches.repos[repoKey]
+	if !ok || c.state != state {
+		// Для каждого репозитория хранится только последнее состояние
+		c = &gitStateCache{state: state, values: map[string]any{}}
+		if gitCaches.repos == nil {
+			gitCaches.repos = map[string]*gitStateCache{}
+		}
+		gitCaches.repos[repoKey] = c
+	}
+	return c
+}
+
+func (e *ExecGitRunner) cacheKey() string {
+	return fmt.Sprintf("exec:%s:%q", e.GitPath, e.Env)
+}
+
+// gitStateCache хранит результаты запросов, зависящих только от коммитов
+// и ссылок: данные HEAD, describe, релизные теги и журналы изменений
+type gitStateCache struct {
+	state  string
+	mu     sync.Mutex
+	values map[string]any
+}
+
+var gitCaches struct {
+	mu    sync.Mutex
+	repos map[string]*gitStateCache
+}
+
+// cachedValue возвращает значение key из кэша c или вычисляет его через compute.
+// nil кэш всегда вызывает compute; ошибки не кэшируются.
+func cachedValue[T any](c *gitStateCache, key string, compute func() (T, error)) (T, error) {
+	if c != nil {
+		c.mu.Lock()
+		v, ok := c.values[key]
+		c.mu.Unlock()
+		if ok {
+			return v.(T), nil
+		}
+	}
+
+	v, err := compute()
+	if err == nil && c != nil {
+		c.mu.Lock()
+		c.values[key] = v
+		c.mu.Unlock()
+	}
+	return v, err
+}
+
+// stateCacheKey строит ключ кэша запроса kind с аргументами from, to и paths
+func stateCacheKey(kind, from, to string, paths []string) string {
+	return strings.Join(append([]string{kind, from, to}, paths...), "\x00")
+}
+
+// cachedChangelog работает как cachedValue, но возвращает копию журнала,
+// чтобы изменения вызывающего (ExtractReferences) не попадали в кэш
+func cachedChangelog(c *gitStateCache, key string, compute func() (*Changelog, error)) (*Changelog, error) {
+	cl, err := cachedValue(c, key, compute)
+	if err != nil {
+		return nil, err
+	}
+	return cl.clone(), nil
+}
+
+// head возвращает копию кэшированных данных коммита HEAD без состояния рабочей копии
+func (c *gitStateCache) head(compute func() (*GitMetadata, error)) (*GitMetadata, error) {
+	meta, err := cachedValue(c, "head", func() (*GitMetadata, error) {
+		meta, err := compute()
+		if err != nil {
+			return nil, err
+		}
+		meta.WorkTreeStatus = WorkTreeStatus{}
+		return meta, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meta.clone(), nil
+}
+
+// ResetGitCache очищает кэш данных репозиториев
+func ResetGitCache() {
+	gitCaches.mu.Lock()
+	defer gitCaches.mu.Unlock()
+	gitCaches.repos = nil
+}
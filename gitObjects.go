@@ -0,0 +1,461 @@
+package mkversions
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Типы объектов в pack-файлах
+const (
+	packObjCommit   = 1
+	packObjTree     = 2
+	packObjBlob     = 3
+	packObjTag      = 4
+	packObjOfsDelta = 6
+	packObjRefDelta = 7
+)
+
+// maxDeltaChain ограничивает глубину цепочки дельт при чтении pack-файлов
+const maxDeltaChain = 4096
+
+var errObjectNotFound = errors.New("git object not found")
+
+// gitObject - распакованный объект git
+type gitObject struct {
+	Type string
+	Data []byte
+}
+
+// objectStore читает loose-объекты и pack-файлы из каталогов objects
+type objectStore struct {
+	dirs []string
+
+	once  sync.Once
+	packs []*packFile
+	err   error
+}
+
+func newObjectStore(objectsDir string) *objectStore {
+	dirs := []string{objectsDir}
+	// Дополнительные хранилища из objects/info/alternates
+	if data, err := os.ReadFile(filepath.Join(objectsDir, "info", "alternates")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !filepath.IsAbs(line) {
+				line = filepath.Join(objectsDir, line)
+			}
+			dirs = append(dirs, line)
+		}
+	}
+	return &objectStore{dirs: dirs}
+}
+
+// read возвращает объект по полному хэшу
+func (s *objectStore) read(hash string) (*gitObject, error) {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != 20 {
+		return nil, fmt.Errorf("invalid object hash %q", hash)
+	}
+
+	for _, dir := range s.dirs {
+		obj, err := readLooseObject(filepath.Join(dir, hash[:2], hash[2:]))
+		if err == nil {
+			return obj, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read object %s: %v", hash, err)
+		}
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		offset, ok := p.find(raw)
+		if !ok {
+			continue
+		}
+		typ, data, err := p.readAt(s, offset, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object %s from %s: %v", hash, filepath.Base(p.path), err)
+		}
+		return &gitObject{Type: packTypeName(typ), Data: data}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errObjectNotFound, hash)
+}
+
+// findPrefix возвращает хэши объектов, начинающиеся с prefix в нижнем регистре.
+// Поиск останавливается на двух совпадениях: этого достаточно, чтобы
+// обнаружить неоднозначный сокращенный хэш.
+func (s *objectStore) findPrefix(prefix string) ([]string, error) {
+	var found []string
+	add := func(hash string) {
+		if !slices.Contains(found, hash) {
+			found = append(found, hash)
+		}
+	}
+
+	for _, dir := range s.dirs {
+		entries, err := os.ReadDir(filepath.Join(dir, prefix[:2]))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to list objects: %v", err)
+		}
+		for _, e := range entries {
+			if hash := prefix[:2] + e.Name(); isObjectHash(hash) && strings.HasPrefix(hash, prefix) {
+				add(hash)
+			}
+		}
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		n := int(p.fanout[255])
+		i := sort.Search(n, func(i int) bool {
+			return hex.EncodeToString(p.hashes[i*20:(i+1)*20]) >= prefix
+		})
+		for ; i < n && len(found) < 2; i++ {
+			hash := hex.EncodeToString(p.hashes[i*20 : (i+1)*20])
+			if !strings.HasPrefix(hash, prefix) {
+				break
+			}
+			add(hash)
+		}
+	}
+	return found, nil
+}
+
+func (s *objectStore) loadPacks() ([]*packFile, error) {
+	s.once.Do(func() {
+		for _, dir := range s.dirs {
+			idxFiles, _ := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+			sort.Strings(idxFiles)
+			for _, idx := range idxFiles {
+				p, err := openPackIndex(idx)
+				if err != nil {
+					s.err = err
+					return
+				}
+				s.packs = append(s.packs, p)
+			}
+		}
+	})
+	return s.packs, s.err
+}
+
+// readLooseObject читает объект из objects/xx/yyyy...
+func readLooseObject(path string) (*gitObject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	nul := bytes.IndexByte(data, 0)
+	if nul == -1 {
+		return nil, fmt.Errorf("malformed loose object header")
+	}
+	header := strings.SplitN(string(data[:nul]), " ", 2)
+	if len(header) != 2 {
+		return nil, fmt.Errorf("malformed loose object header %q", data[:nul])
+	}
+	size, err := strconv.Atoi(header[1])
+	if err != nil || size != len(data)-nul-1 {
+		return nil, fmt.Errorf("malformed loose object size %q", header[1])
+	}
+	return &gitObject{Type: header[0], Data: data[nul+1:]}, nil
+}
+
+// packFile - pack-файл и его индекс версии 2
+type packFile struct {
+	path    string
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []byte
+	large   []byte
+}
+
+func openPackIndex(idxPath string) (*packFile, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index: %v", err)
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:]) != 2 {
+		return nil, fmt.Errorf("unsupported pack index format in %s", filepath.Base(idxPath))
+	}
+
+	p := &packFile{path: strings.TrimSuffix(idxPath, ".idx") + ".pack"}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(data[8+i*4:])
+	}
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(data) < pos+n*20+n*4+n*4 {
+		return nil, fmt.Errorf("truncated pack index %s", filepath.Base(idxPath))
+	}
+	p.hashes = data[pos : pos+n*20]
+	pos += n*20 + n*4 // пропускаем CRC32
+	p.offsets = data[pos : pos+n*4]
+	pos += n * 4
+	p.large = data[pos:]
+	return p, nil
+}
+
+// find ищет смещение объекта в pack-файле
+func (p *packFile) find(hash []byte) (int64, bool) {
+	lo := 0
+	if hash[0] > 0 {
+		lo = int(p.fanout[hash[0]-1])
+	}
+	hi := int(p.fanout[hash[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*20:(lo+i+1)*20], hash) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.hashes[i*20:(i+1)*20], hash) {
+		return 0, false
+	}
+
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+	idx := int(offset&0x7fffffff) * 8
+	if idx+8 > len(p.large) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.large[idx:])), true
+}
+
+// readAt читает объект по смещению, применяя дельты
+func (p *packFile) readAt(s *objectStore, offset int64, depth int) (int, []byte, error) {
+	if depth > maxDeltaChain {
+		return 0, nil, fmt.Errorf("delta chain is too long")
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := int(b>>4) & 7
+	size := uint64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= uint64(b&0x7f) << shift
+	}
+
+	switch typ {
+	case packObjCommit, packObjTree, packObjBlob, packObjTag:
+		data, err := inflate(r, size)
+		return typ, data, err
+
+	case packObjOfsDelta:
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			rel = (rel+1)<<7 | int64(b&0x7f)
+		}
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err := p.readAt(s, offset-rel, depth+1)
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	case packObjRefDelta:
+		var baseHash [20]byte
+		if _, err := io.ReadFull(r, baseHash[:]); err != nil {
+			return 0, nil, err
+		}
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		base, err := s.read(hex.EncodeToString(baseHash[:]))
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base.Data, delta)
+		return packTypeCode(base.Type), data, err
+	}
+	return 0, nil, fmt.Errorf("unknown pack object type %d", typ)
+}
+
+func inflate(r io.Reader, size uint64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, fmt.Errorf("inflated size %d does not match %d", len(data), size)
+	}
+	return data, nil
+}
+
+// applyDelta восстанавливает объект из базового объекта и дельты
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+	readSize := func() (uint64, error) {
+		var size uint64
+		for shift := 0; ; shift += 7 {
+			if pos >= len(delta) {
+				return 0, fmt.Errorf("truncated delta header")
+			}
+			b := delta[pos]
+			pos++
+			size |= uint64(b&0x7f) << shift
+			if b&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+
+	baseSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base size %d does not match %d", baseSize, len(base))
+	}
+	resultSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	// resultSize читается из пака и не проверен: каждая команда копирует не
+	// больше 64 КБ, а начальная емкость ограничена размером входных данных
+	if resultSize > uint64(len(delta))*0x10000 {
+		return nil, fmt.Errorf("delta result size %d is too large for a %d byte delta", resultSize, len(delta))
+	}
+	out := make([]byte, 0, min(resultSize, uint64(len(base)+len(delta))))
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		switch {
+		case op&0x80 != 0:
+			// Копирование из базового объекта
+			var offset, size uint32
+			for i := uint(0); i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if pos >= len(delta) {
+						return nil, fmt.Errorf("truncated delta copy")
+					}
+					offset |= uint32(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if pos >= len(delta) {
+						return nil, fmt.Errorf("truncated delta copy")
+					}
+					size |= uint32(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if uint64(offset)+uint64(size) > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copy out of range")
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			// Вставка следующих op байт
+			if pos+int(op) > len(delta) {
+				return nil, fmt.Errorf("truncated delta insert")
+			}
+			out = append(out, delta[pos:pos+int(op)]...)
+			pos += int(op)
+		default:
+			return nil, fmt.Errorf("invalid delta opcode 0")
+		}
+		if uint64(len(out)) > resultSize {
+			return nil, fmt.Errorf("delta result exceeds declared size %d", resultSize)
+		}
+	}
+	if uint64(len(out)) != resultSize {
+		return nil, fmt.Errorf("delta result size %d does not match %d", len(out), resultSize)
+	}
+	return out, nil
+}
+
+func packTypeName(typ int) string {
+	switch typ {
+	case packObjCommit:
+		return "commit"
+	case packObjTree:
+		return "tree"
+	case packObjBlob:
+		return "blob"
+	case packObjTag:
+		return "tag"
+	}
+	return ""
+}
+
+func packTypeCode(name string) int {
+	switch name {
+	case "commit":
+		return packObjCommit
+	case "tree":
+		return packObjTree
+	case "blob":
+		return packObjBlob
+	case "tag":
+		return packObjTag
+	}
+	return 0
+}
@@ -0,0 +1,629 @@
+package mkversions
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedGitCommand возвращается NativeGitRunner для команд,
+// которые нельзя выполнить без исполняемого git
+var ErrUnsupportedGitCommand = errors.New("git command is not supported without git executable")
+
+// maxSymrefDepth ограничивает глубину цепочки символических ссылок
+const maxSymrefDepth = 5
+
+// minAbbrevLength - минимальная длина сокращенного хэша, как в git
+const minAbbrevLength = 4
+
+// errUnsupportedRevision возвращается для выражений ревизий, которые
+// NativeGitRunner не разбирает сам и передает Fallback
+var errUnsupportedRevision = errors.New("unsupported revision expression")
+
+// refReader - необязательный интерфейс GitRunner, который отвечает на запросы
+// хэша, ветки и даты коммита без запуска команд git
+type refReader interface {
+	resolveRef(ctx context.Context, ref string) (string, error)
+	branchName(ctx context.Context) (string, error)
+	commitTime(ctx context.Context, ref string) (time.Time, error)
+	headMetadata(ctx context.Context) (*GitMetadata, error)
+}
+
+// NativeGitRunner читает метаданные напрямую из каталога .git: HEAD, ссылки,
+// packed-refs, loose- и pack-объекты. Поддерживаются рабочие деревья
+// git worktree, где .git - файл со ссылкой на каталог репозитория.
+// Хэш, ветка и дата коммита читаются без git; остальные команды и выражения
+// ревизий вроде rev@{upstream} передаются Fallback, а без него завершаются
+// ошибкой.
+type NativeGitRunner struct {
+	Dir      string
+	Fallback GitRunner
+}
+
+// Run передает команду Fallback
+func (n *NativeGitRunner) Run(ctx context.Context, args ...string) (string, error) {
+	if n.Fallback != nil {
+		return n.Fallback.Run(ctx, args...)
+	}
+	return "", &GitError{Args: args, Err: ErrUnsupportedGitCommand}
+}
+
+func (n *NativeGitRunner) resolveRef(ctx context.Context, ref string) (string, error) {
+	repo, err := n.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	return n.resolve(ctx, repo, ref)
+}
+
+func (n *NativeGitRunner) branchName(ctx context.Context) (string, error) {
+	repo, err := n.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	return repo.branchName()
+}
+
+func (n *NativeGitRunner) commitTime(ctx context.Context, ref string) (time.Time, error) {
+	repo, err := n.open(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	hash, err := n.resolve(ctx, repo, ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return repo.commitTime(hash)
+}
+
+// resolve раскрывает ревизию; выражения, которые nativeRepo не разбирает,
+// передаются git rev-parse через Fallback
+func (n *NativeGitRunner) resolve(ctx context.Context, repo *nativeRepo, rev string) (string, error) {
+	hash, err := repo.resolve(rev)
+	if !errors.Is(err, errUnsupportedRevision) || n.Fallback == nil {
+		return hash, err
+	}
+	stdout, err := n.Fallback.Run(ctx, "rev-parse", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func (n *NativeGitRunner) open(ctx context.Context) (*nativeRepo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dir := n.Dir
+	if dir == "" {
+		dir = "."
+	}
+	return openNativeRepo(dir)
+}
+
+// nativeRepo - найденный каталог репозитория. Для рабочего дерева gitDir
+// содержит его HEAD, а commonDir - общие ссылки и объекты.
+type nativeRepo struct {
+	gitDir    string
+	commonDir string
+	objects   *objectStore
+}
+
+// openNativeRepo ищет .git в dir и родительских каталогах
+func openNativeRepo(dir string) (*nativeRepo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir := ""
+	if isGitDir(abs) {
+		gitDir = abs
+	}
+	for d := abs; gitDir == ""; {
+		dotGit := filepath.Join(d, ".git")
+		fi, err := os.Stat(dotGit)
+		switch {
+		case err == nil && fi.IsDir():
+			gitDir = dotGit
+		case err == nil:
+			gitDir, err = readGitDirFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			parent := filepath.Dir(d)
+			if parent == d {
+				return nil, fmt.Errorf("%w: %s", ErrNotARepository, abs)
+			}
+			d = parent
+		}
+	}
+
+	repo := &nativeRepo{gitDir: gitDir, commonDir: gitDir}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		repo.commonDir = filepath.Clean(common)
+	}
+	repo.objects = newObjectStore(filepath.Join(repo.commonDir, "objects"))
+	return repo, nil
+}
+
+// isGitDir сообщает, похож ли каталог на каталог репозитория (в том числе bare)
+func isGitDir(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(dir, "objects")); err == nil {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "commondir"))
+	return err == nil
+}
+
+// readGitDirFile читает файл .git вида "gitdir: <путь>"
+func readGitDirFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("%w: malformed %s", ErrNotARepository, path)
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// readRef читает ссылку из файла или packed-refs. Возвращает хэш
+// или "ref: <имя>" для символической ссылки.
+func (r *nativeRepo) readRef(name string) (string, bool, error) {
+	dirs := []string{r.gitDir}
+	if r.commonDir != r.gitDir {
+		dirs = append(dirs, r.commonDir)
+	}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil {
+			return strings.TrimSpace(string(data)), true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) && !isDirError(err) {
+			return "", false, err
+		}
+	}
+
+	if !strings.HasPrefix(name, "refs/") {
+		return "", false, nil
+	}
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, refName, ok := strings.Cut(line, " ")
+		if ok && refName == name {
+			return hash, true, nil
+		}
+	}
+	return "", false, sc.Err()
+}
+
+// isDirError сообщает, что по пути ссылки лежит каталог (например refs/heads)
+func isDirError(err error) bool {
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) {
+		return false
+	}
+	fi, statErr := os.Stat(pathErr.Path)
+	return statErr == nil && fi.IsDir()
+}
+
+// resolveFull раскрывает символические ссылки до хэша
+func (r *nativeRepo) resolveFull(name string) (string, bool, error) {
+	for depth := 0; depth <= maxSymrefDepth; depth++ {
+		value, ok, err := r.readRef(name)
+		if err != nil || !ok {
+			return "", ok, err
+		}
+		if target, isSym := strings.CutPrefix(value, "ref: "); isSym {
+			name = strings.TrimSpace(target)
+			continue
+		}
+		if !isObjectHash(value) {
+			return "", false, fmt.Errorf("malformed ref %s: %q", name, value)
+		}
+		return strings.ToLower(value), true, nil
+	}
+	return "", false, fmt.Errorf("symbolic ref %s is too deep", name)
+}
+
+// resolve раскрывает ревизию так же, как git rev-parse: полный или сокращенный
+// хэш либо имя ссылки, которое ищется в порядке refs/, refs/tags/, refs/heads/,
+// refs/remotes/, с суффиксами ~N, ^N, ^{} и ^{commit}. Для прочих выражений
+// (@{...}, :путь, диапазоны) возвращается errUnsupportedRevision.
+func (r *nativeRepo) resolve(rev string) (string, error) {
+	if rev == "" || rev == "@" {
+		rev = "HEAD"
+	}
+	base, ops := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		base, ops = rev[:i], rev[i:]
+	}
+	if base == "@" {
+		base = "HEAD"
+	}
+	if base == "" || strings.Contains(base, "@{") || strings.ContainsAny(base, ": ") || strings.Contains(rev, "..") {
+		return "", fmt.Errorf("%w: %q", errUnsupportedRevision, rev)
+	}
+
+	hash, err := r.resolveBase(base)
+	if err != nil {
+		return "", err
+	}
+	for ops != "" {
+		op := ops[0]
+		ops = ops[1:]
+		if op == '^' && strings.HasPrefix(ops, "{") {
+			spec, rest, ok := strings.Cut(ops[1:], "}")
+			if !ok || spec != "" && spec != "commit" {
+				return "", fmt.Errorf("%w: %q", errUnsupportedRevision, rev)
+			}
+			ops = rest
+			var obj *gitObject
+			if hash, obj, err = r.peel(hash); err != nil {
+				return "", err
+			}
+			if spec == "commit" && obj.Type != "commit" {
+				return "", fmt.Errorf("unknown revision %q", rev)
+			}
+			continue
+		}
+
+		n, digits := 1, 0
+		for digits < len(ops) && ops[digits] >= '0' && ops[digits] <= '9' {
+			digits++
+		}
+		if digits > 0 {
+			if n, err = strconv.Atoi(ops[:digits]); err != nil {
+				return "", fmt.Errorf("unknown revision %q", rev)
+			}
+			ops = ops[digits:]
+		}
+
+		// rev~N - N-й предок по первым родителям, rev^N - N-й родитель;
+		// rev~0 и rev^0 раскрывают тег до коммита
+		steps, nth := 1, n
+		if op == '~' {
+			steps, nth = max(n, 1), min(n, 1)
+		}
+		for i := 0; i < steps; i++ {
+			commit, err := r.readCommit(hash)
+			if err != nil {
+				return "", err
+			}
+			if nth > len(commit.parents) {
+				return "", fmt.Errorf("unknown revision %q", rev)
+			}
+			hash = commit.hash
+			if nth > 0 {
+				hash = commit.parents[nth-1]
+			}
+		}
+	}
+	return hash, nil
+}
+
+// resolveBase раскрывает ревизию без суффиксов. Как и в git, имя ссылки
+// имеет приоритет над сокращенным хэшем.
+func (r *nativeRepo) resolveBase(rev string) (string, error) {
+	if isObjectHash(rev) {
+		return strings.ToLower(rev), nil
+	}
+
+	candidates := []string{rev, "refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev, "refs/remotes/" + rev + "/HEAD"}
+	for _, name := range candidates {
+		hash, ok, err := r.resolveFull(name)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return hash, nil
+		}
+	}
+
+	if len(rev) >= minAbbrevLength && isHex(rev) {
+		hashes, err := r.objects.findPrefix(strings.ToLower(rev))
+		if err != nil {
+			return "", err
+		}
+		switch len(hashes) {
+		case 1:
+			return hashes[0], nil
+		case 0:
+		default:
+			return "", fmt.Errorf("short object ID %s is ambiguous", rev)
+		}
+	}
+	return "", fmt.Errorf("unknown revision %q", rev)
+}
+
+// branchName возвращает ветку, на которую указывает HEAD, или "HEAD"
+// для отсоединенного HEAD, как git rev-parse --abbrev-ref HEAD
+func (r *nativeRepo) branchName() (string, error) {
+	value, ok, err := r.readRef("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no HEAD", ErrNotARepository, r.gitDir)
+	}
+	if _, err := r.resolve("HEAD"); err != nil {
+		return "", err
+	}
+
+	target, isSym := strings.CutPrefix(value, "ref: ")
+	if !isSym {
+		return "HEAD", nil
+	}
+	target = strings.TrimSpace(target)
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if short, ok := strings.CutPrefix(target, prefix); ok {
+			return short, nil
+		}
+	}
+	return target, nil
+}
+
+// commitTime возвращает дату коммита rev; аннотированные теги раскрываются
+func (r *nativeRepo) commitTime(rev string) (time.Time, error) {
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	commit, err := r.readCommit(hash)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return commit.commitDate, nil
+}
+
+// nativeCommit - заголовки коммита, нужные для метаданных
+type nativeCommit struct {
+	hash       string
+	parents    []string
+	authorDate time.Time
+	commitDate time.Time
+}
+
+// readCommit читает коммит, раскрывая аннотированные теги
+func (r *nativeRepo) readCommit(hash string) (*nativeCommit, error) {
+	hash, obj, err := r.peel(hash)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", hash, obj.Type)
+	}
+
+	commit := &nativeCommit{hash: hash}
+	if commit.authorDate, err = signatureTime(objectHeader(obj.Data, "author")); err != nil {
+		return nil, fmt.Errorf("malformed author in commit %s: %v", hash, err)
+	}
+	if commit.commitDate, err = signatureTime(objectHeader(obj.Data, "committer")); err != nil {
+		return nil, fmt.Errorf("malformed committer in commit %s: %v", hash, err)
+	}
+	for _, line := range strings.Split(string(obj.Data), "\n") {
+		if line == "" {
+			break
+		}
+		if parent, ok := strings.CutPrefix(line, "parent "); ok {
+			commit.parents = append(commit.parents, parent)
+		}
+	}
+	return commit, nil
+}
+
+// peel раскрывает цепочку аннотированных тегов до объекта другого типа
+func (r *nativeRepo) peel(hash string) (string, *gitObject, error) {
+	for depth := 0; depth <= maxSymrefDepth; depth++ {
+		obj, err := r.objects.read(hash)
+		if err != nil {
+			return "", nil, err
+		}
+		if obj.Type != "tag" {
+			return hash, obj, nil
+		}
+		target := objectHeader(obj.Data, "object")
+		if !isObjectHash(target) {
+			return "", nil, fmt.Errorf("malformed tag object %s", hash)
+		}
+		hash = target
+	}
+	return "", nil, fmt.Errorf("tag chain at %s is too deep", hash)
+}
+
+// tagsAt возвращает отсортированные имена тегов, указывающих на коммит hash
+func (r *nativeRepo) tagsAt(hash string) ([]string, error) {
+	tags := map[string]string{}
+	// peeled - раскрытые значения аннотированных тегов из packed-refs
+	peeled := map[string]string{}
+
+	if f, err := os.Open(filepath.Join(r.commonDir, "packed-refs")); err == nil {
+		sc := bufio.NewScanner(f)
+		var last string
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "" || line[0] == '#':
+			case line[0] == '^':
+				if last != "" {
+					peeled[last] = line[1:]
+				}
+			default:
+				value, name, _ := strings.Cut(line, " ")
+				last = ""
+				if tag, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+					tags[tag], last = value, tag
+				}
+			}
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	tagsDir := filepath.Join(r.commonDir, "refs", "tags")
+	err := filepath.WalkDir(tagsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(tagsDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tag := filepath.ToSlash(rel)
+		tags[tag] = strings.TrimSpace(string(data))
+		delete(peeled, tag)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var result []string
+	for tag, value := range tags {
+		if !isObjectHash(value) {
+			continue
+		}
+		target, ok := peeled[tag]
+		if !ok && strings.EqualFold(value, hash) {
+			target = value
+		} else if !ok {
+			if target, _, err = r.peel(strings.ToLower(value)); err != nil {
+				return nil, err
+			}
+		}
+		if strings.EqualFold(target, hash) {
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// refsFingerprint возвращает SHA-256 от packed-refs и всех файлов в refs/.
+// Он меняется при создании, удалении и перемещении веток и тегов.
+func (r *nativeRepo) refsFingerprint() (string, error) {
+	h := sha256.New()
+	if data, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs")); err == nil {
+		h.Write(data)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	refsDir := filepath.Join(r.commonDir, "refs")
+	err := filepath.WalkDir(refsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Файл блокировки или ссылка, удаленная во время обхода
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "\x00%s\x00%s", filepath.ToSlash(strings.TrimPrefix(path, refsDir)), data)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// signatureTime извлекает метку времени из строки "Имя <email> секунды +hhmm"
+func signatureTime(signature string) (time.Time, error) {
+	fields := strings.Fields(signature[strings.LastIndexByte(signature, '>')+1:])
+	if len(fields) != 2 {
+		return time.Time{}, fmt.Errorf("unexpected signature %q", signature)
+	}
+	return parseGitTime(fields[0], fields[1])
+}
+
+// objectHeader возвращает значение поля заголовка коммита или тега
+func objectHeader(data []byte, key string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, key+" "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseGitTime разбирает метку времени git "<секунды> <+hhmm>" с сохранением часового пояса
+func parseGitTime(seconds, zone string) (time.Time, error) {
+	ts, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse Git timestamp %q: %v", seconds, err)
+	}
+	if len(zone) != 5 || (zone[0] != '+' && zone[0] != '-') || !isDigits(zone[1:]) {
+		return time.Time{}, fmt.Errorf("failed to parse Git time zone %q", zone)
+	}
+	hours, _ := strconv.Atoi(zone[1:3])
+	minutes, _ := strconv.Atoi(zone[3:5])
+	offset := hours*3600 + minutes*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+	return time.Unix(ts, 0).In(time.FixedZone("", offset)), nil
+}
+
+func isObjectHash(s string) bool {
+	return len(s) == 40 && isHex(s)
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// newRevisionTestRepo создает историю со слиянием, легким и аннотированным тегами:
+//
+//	a - b - c - merge (main)
+//	     \     /
+//	      f1  (feature)
+func newRevisionTestRepo(t *testing.T) *testGitRepo {
+	repo := newTestGitRepo(t)
+	repo.commit("a.txt", "a\n", "feat: a")
+	repo.git("tag", "-a", "-m", "release", "v1.0.0")
+	repo.commit("b.txt", "b\n", "fix: b")
+	repo.git("tag", "v1.0.1")
+	repo.git("checkout", "-q", "-b", "feature")
+	repo.commit("f.txt", "f\n", "feat: f1")
+	repo.git("checkout", "-q", "main")
+	repo.commit("c.txt", "c\n", "fix: c")
+	repo.git("merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	return repo
+}
+
+// checkNativeParity сравнивает ответы NativeGitRunner и git для ревизий в dir
+func checkNativeParity(t *testing.T, repo *testGitRepo, dir string) {
+	t.Helper()
+	ctx := context.Background()
+	execRepo := NewRepo(&ExecGitRunner{Dir: dir})
+	nativeRepo := NewRepo(&NativeGitRunner{Dir: dir, Fallback: &ExecGitRunner{Dir: dir}})
+
+	head := repo.git("-C", dir, "rev-parse", "HEAD")
+	revs := []string{
+		"", "HEAD", "@", "HEAD~", "HEAD~1", "HEAD~2", "HEAD~0", "HEAD^", "HEAD^1", "HEAD^2", "HEAD^^", "HEAD^0",
+		"HEAD^2~1", "main~3", "feature", "refs/heads/feature",
+		"v1.0.0", "v1.0.0^{}", "v1.0.0^{commit}", "v1.0.0~0", "v1.0.1^",
+		head, head[:7], head[:4] + "^",
+		// Передаются Fallback
+		"HEAD@{0}", "main@{0}~1",
+	}
+	for _, rev := range revs {
+		want, err := execRepo.CommitHashFull(ctx, rev)
+		if err != nil {
+			t.Fatalf("git rev-parse %q: %v", rev, err)
+		}
+		got, err := nativeRepo.CommitHashFull(ctx, rev)
+		if err != nil || got != want {
+			t.Errorf("CommitHashFull(%q) = %q, %v; want %q", rev, got, err, want)
+		}
+
+		wantShort, _ := execRepo.CommitHashShort(ctx, rev)
+		if got, err := nativeRepo.CommitHashShort(ctx, rev); err != nil || got != wantShort {
+			t.Errorf("CommitHashShort(%q) = %q, %v; want %q", rev, got, err, wantShort)
+		}
+
+		wantDate, err := execRepo.CommitDate(ctx, rev)
+		if err != nil {
+			t.Fatalf("git log %q: %v", rev, err)
+		}
+		if got, err := nativeRepo.CommitDate(ctx, rev); err != nil || !got.Equal(wantDate) || got.String() != wantDate.String() {
+			t.Errorf("CommitDate(%q) = %v, %v; want %v", rev, got, err, wantDate)
+		}
+	}
+
+	for _, rev := range []string{"HEAD~10", "HEAD^3", "missing", "v1.0.0^{tree}x"} {
+		if _, err := execRepo.CommitHashFull(ctx, rev); err == nil {
+			t.Fatalf("git rev-parse %q succeeded", rev)
+		}
+		if hash, err := nativeRepo.CommitHashFull(ctx, rev); err == nil {
+			t.Errorf("CommitHashFull(%q) = %q, want error", rev, hash)
+		}
+	}
+}
+
+func TestNativeGitRunnerParityLoose(t *testing.T) {
+	repo := newRevisionTestRepo(t)
+	checkNativeParity(t, repo, repo.dir)
+}
+
+func TestNativeGitRunnerParityPacked(t *testing.T) {
+	repo := newRevisionTestRepo(t)
+	repo.git("gc", "-q", "--prune=now")
+	if loose := repo.git("count-objects"); loose != "0 objects, 0 kilobytes" {
+		t.Fatalf("objects are not packed: %s", loose)
+	}
+	checkNativeParity(t, repo, repo.dir)
+}
+
+func TestNativeGitRunnerParityWorktree(t *testing.T) {
+	repo := newRevisionTestRepo(t)
+	wt := filepath.Join(t.TempDir(), "wt")
+	repo.git("worktree", "add", "-q", "-b", "wt", wt, "main")
+	checkNativeParity(t, repo, wt)
+}
+
+func TestNativeGitRunnerUnsupportedRevision(t *testing.T) {
+	repo := newRevisionTestRepo(t)
+	native := NewRepo(&NativeGitRunner{Dir: repo.dir})
+	for _, rev := range []string{"HEAD@{0}", "main@{upstream}", "HEAD:a.txt", "HEAD~1..HEAD", "^HEAD"} {
+		if _, err := native.CommitHashFull(context.Background(), rev); !errors.Is(err, errUnsupportedRevision) {
+			t.Errorf("CommitHashFull(%q) error = %v, want errUnsupportedRevision", rev, err)
+		}
+	}
+}
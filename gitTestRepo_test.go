@@ -0,0 +1,74 @@
+package mkversions
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testGitRepo - временный репозиторий для тестов с исполняемым git
+type testGitRepo struct {
+	t   *testing.T
+	dir string
+	// commits - число коммитов, от него зависят даты следующих
+	commits int
+}
+
+// newTestGitRepo создает пустой репозиторий с веткой main.
+// Тест пропускается, если git не установлен.
+func newTestGitRepo(t *testing.T) *testGitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &testGitRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q", "-b", "main")
+	r.git("config", "user.name", "Test")
+	r.git("config", "user.email", "test@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	r.git("config", "tag.gpgsign", "false")
+	return r
+}
+
+// git запускает git в каталоге репозитория и возвращает stdout без пробелов по краям
+func (r *testGitRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	date := fmt.Sprintf("%d +0300", 1700000000+r.commits*3600)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date, "LC_ALL=C")
+	out, err := cmd.Output()
+	if err != nil {
+		var stderr []byte
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = exitErr.Stderr
+		}
+		r.t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, stderr)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// write записывает файл относительно корня репозитория
+func (r *testGitRepo) write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit записывает файл, коммитит его с сообщением message и возвращает хэш
+func (r *testGitRepo) commit(name, content, message string) string {
+	r.t.Helper()
+	r.write(name, content)
+	r.git("add", name)
+	r.git("commit", "-q", "-m", message)
+	r.commits++
+	return r.git("rev-parse", "HEAD")
+}
@@ -1,8 +1,12 @@
 package mkversions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,130 +16,363 @@ type GITInfo struct {
 	CommitHashShort string
 	BranchName      string
 	CommitDate      time.Time
+	Tags            []string
 	ChangelogSince  time.Time
+	ChangelogFrom   string
+	ChangelogTo     string
+	IsDirty         bool
+	ModifiedFiles   []string
+	UntrackedFiles  []string
+	DiffHash        string
 	*Changelog
 }
 
 type Changelog struct {
 	Entries []string
 	Commits []CommitDetails
+
+	// Шаблоны ссылок на задачи, см. ExtractReferences
+	patterns []ReferencePattern
 }
 
+// CommitDetails описывает один коммит журнала изменений
 type CommitDetails struct {
-	Hash    string `json:"hash"`
-	Message string `json:"message"`
-	Author  string `json:"author"`
-	Email   string `json:"email"`
-	Date    string `json:"date"`
+	Hash           string    `json:"hash"`
+	FullHash       string    `json:"fullHash"`
+	Parents        []string  `json:"parents,omitempty"`
+	Message        string    `json:"message"`
+	Body           string    `json:"body,omitempty"`
+	Trailers       []Trailer `json:"trailers,omitempty"`
+	Author         string    `json:"author"`
+	Email          string    `json:"email"`
+	Date           string    `json:"date"`
+	AuthorDate     time.Time `json:"authorDate"`
+	Committer      string    `json:"committer"`
+	CommitterEmail string    `json:"committerEmail"`
+	CommitterDate  time.Time `json:"committerDate"`
+	// Additions и Deletions - число добавленных и удаленных строк (git log --numstat);
+	// заполняются только Repo.ChangelogStats, Repo.ChangelogRangeStats и WithChangelogStats
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	// References - ссылки на задачи из сообщения, см. Changelog.ExtractReferences
+	References []Reference `json:"references,omitempty"`
+}
+
+// Trailer - строка-трейлер сообщения коммита, например "Signed-off-by: ..."
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
+// PrepareGit заполняет GITInfo из репозитория, оставляя значения по умолчанию
+// для полей, которые получить не удалось. Причины записываются в Warnings.
 func (info *Info) PrepareGit() {
-	if info.GITInfo.BranchName == "unknown" {
-		var branchErr error
-		info.GITInfo.BranchName, branchErr = GetGitBranchName()
-		if branchErr != nil {
-			fmt.Println("Error while getting git branch name: ", branchErr)
-			info.GITInfo.BranchName = ""
-		}
+	_ = info.PrepareGitE()
+}
+
+// PrepareGitE работает как PrepareGit, но возвращает все возникшие ошибки,
+// объединенные через errors.Join
+func (info *Info) PrepareGitE() error {
+	return info.PrepareGitContext(context.Background())
+}
+
+// PrepareGitContext работает как PrepareGitE; ctx ограничивает все вызовы git.
+//
+// Для HEAD без модуля выполняется пять команд, каждая отвечает на свой вопрос:
+//   - status - состояние рабочей копии, хэш и ветка HEAD;
+//   - log -1 - даты и теги HEAD;
+//   - describe - ближайший тег и расстояние до него; один проход log дал бы
+//     это только чтением всей истории и повторением обхода графа git describe;
+//   - tag --merged - предыдущий релизный тег, отличный от тега на HEAD;
+//   - log - журнал от этого тега, размер которого ограничен диапазоном.
+//
+// Ветка или модуль добавляют log -1 для их последнего коммита, а модуль -
+// еще ls-files и rev-list. С ExecGitRunner и NativeGitRunner все результаты,
+// кроме status, кэшируются по состоянию HEAD и ссылок, поэтому повторный
+// вызов запускает только status (и diff для измененной рабочей копии).
+func (info *Info) PrepareGitContext(ctx context.Context) error {
+	if info.gitTimeout != nil {
+		ctx = ContextWithGitTimeout(ctx, *info.gitTimeout)
 	}
+	repo := NewRepo(info.gitRunner)
 
-	if info.GITInfo.CommitHash == "unknown" {
-		var hashErr error
-		info.GITInfo.CommitHash, hashErr = GetGitCommitHashFull(info.GITInfo.BranchName)
-		if hashErr != nil {
-			fmt.Println("Error while getting git commit hash: ", hashErr)
-			info.GITInfo.CommitHash = "unknown"
-			info.GITInfo.CommitHashShort = "unknown"
-		}
+	var errs []error
+	fail := func(field string, err error) {
+		errs = append(errs, err)
+		info.addWarning(field, err)
+		info.log().Warn("failed to get git metadata", slog.String("field", field), slog.Any("error", err))
+	}
 
-		if len(info.GITInfo.CommitHash) > 7 {
-			info.GITInfo.CommitHashShort = info.GITInfo.CommitHash[:7]
-		} else {
-			info.GITInfo.CommitHashShort = info.GITInfo.CommitHash
+	// Хэш и дата, заданные опциями, не перезаписываются
+	commitFromGit := info.GITInfo.CommitHash == "unknown"
+	dateFromGit := info.GITInfo.CommitDate.IsZero()
+
+	// Ветка, заданная WithBranchName, определяет коммит, версию и журнал;
+	// иначе используется HEAD
+	ref := info.branchRef
+	cache := repo.stateCache()
+	paths, err := info.modulePaths(ctx, repo)
+	if err != nil {
+		fail("Module", err)
+		paths = []string{":/" + info.Module}
+	}
+
+	meta, metaErr := repo.metadata(ctx, cache, paths, info.dirtyExclude)
+	if metaErr != nil {
+		fail("GITInfo", metaErr)
+		if info.GITInfo.BranchName == "unknown" {
+			info.GITInfo.BranchName = ""
 		}
+	} else {
+		if info.GITInfo.BranchName == "unknown" {
+			info.GITInfo.BranchName = meta.BranchName
+			if meta.Detached {
+				fail("BranchName", ErrDetachedHead)
+				info.GITInfo.BranchName = ""
+			}
+		}
+		if ref == meta.BranchName && !meta.Detached {
+			ref = ""
+		}
+		if ref == "" && paths == nil {
+			if commitFromGit {
+				info.GITInfo.CommitHash = meta.CommitHash
+				info.GITInfo.CommitHashShort = meta.CommitHash[:7]
+			}
+			if dateFromGit {
+				info.GITInfo.CommitDate = meta.CommitDate
+			}
+		}
+		if ref == "" {
+			info.GITInfo.Tags = meta.Tags
+		}
+		info.GITInfo.IsDirty = meta.IsDirty
+		info.GITInfo.ModifiedFiles = meta.ModifiedFiles
+		info.GITInfo.UntrackedFiles = meta.UntrackedFiles
+		info.GITInfo.DiffHash = meta.DiffHash
 	}
 
-	if info.GITInfo.CommitDate.IsZero() {
-		var dateErr error
-		info.GITInfo.CommitDate, dateErr = GetGitCommitDate(info.GITInfo.BranchName)
-		if dateErr != nil {
-			fmt.Println("Error while getting git commit date: ", dateErr)
-			info.GITInfo.CommitDate = time.Time{}
+	if ref != "" || paths != nil && metaErr == nil {
+		if err := info.prepareCommit(ctx, repo, cache, ref, paths, commitFromGit, dateFromGit); err != nil {
+			fail("CommitHash", err)
 		}
 	}
 
-	var logSince time.Time
-	if !info.ChangelogSince.IsZero() {
-		logSince = info.GITInfo.CommitDate.Add(-24 * time.Hour)
-	} else {
-		logSince = time.Now().Add(-24 * time.Hour)
+	if info.versionFromGit || info.Version == "" {
+		describe, describeErr := cachedValue(cache, stateCacheKey("describe", info.TagPrefix(), ref, paths), func() (*GitDescribe, error) {
+			return repo.describeRef(ctx, info.TagPrefix(), ref, paths, false)
+		})
+		if describeErr != nil {
+			fail("Version", describeErr)
+		} else {
+			d := *describe
+			// "-dirty" относится только к HEAD: рабочая копия уже проверена в metadata
+			d.Dirty = ref == "" && meta != nil && len(meta.ModifiedFiles) > 0
+			info.Version = d.String()
+		}
 	}
 
 	var changelogErr error
-	info.GITInfo.Changelog, changelogErr = GetGitChangelog(logSince.Format("2006-01-02"), info.GITInfo.BranchName)
+	switch {
+	case info.changelogRange:
+		to := info.ChangelogTo
+		if to == "" {
+			to = ref
+		}
+		info.GITInfo.Changelog, changelogErr = cachedChangelog(cache, stateCacheKey(info.changelogKind("range"), info.ChangelogFrom, to, paths), func() (*Changelog, error) {
+			return repo.changelogRange(ctx, info.ChangelogFrom, to, paths, info.changelogStats)
+		})
+	case !info.ChangelogSince.IsZero():
+		since := info.ChangelogSince.Format(time.RFC3339)
+		info.GITInfo.Changelog, changelogErr = cachedChangelog(cache, stateCacheKey(info.changelogKind("since"), since, ref, paths), func() (*Changelog, error) {
+			return repo.changelog(ctx, since, ref, paths, info.changelogStats)
+		})
+	default:
+		// От предыдущего релизного тега до HEAD или ветки
+		info.GITInfo.ChangelogFrom, changelogErr = cachedValue(cache, stateCacheKey("prevtag", info.TagPrefix(), ref, nil), func() (string, error) {
+			return repo.PreviousReleaseTag(ctx, info.TagPrefix(), ref)
+		})
+		if changelogErr == nil {
+			info.GITInfo.Changelog, changelogErr = cachedChangelog(cache, stateCacheKey(info.changelogKind("range"), info.ChangelogFrom, ref, paths), func() (*Changelog, error) {
+				return repo.changelogRange(ctx, info.ChangelogFrom, ref, paths, info.changelogStats)
+			})
+		}
+	}
 	if changelogErr != nil {
-		fmt.Println("Error while getting git changelog: ", changelogErr)
+		fail("Changelog", changelogErr)
 		info.GITInfo.Changelog = &Changelog{}
 	}
+	if info.referencePatterns != nil {
+		info.GITInfo.Changelog.ExtractReferences(info.referencePatterns)
+	}
+
+	return errors.Join(errs...)
+}
+
+// changelogKind добавляет к виду запроса журнала признак статистики строк для ключа кэша
+func (info *Info) changelogKind(kind string) string {
+	if info.changelogStats {
+		return kind + "+stats"
+	}
+	return kind
 }
 
-func GetGitCommitHashFull(ref string) (string, error) {
+// GetGitCommitHashFull возвращает полный хэш коммита ref или HEAD. Если заданы
+// paths (pathspec git), берется последний коммит до ref, затрагивающий их.
+func GetGitCommitHashFull(ref string, paths ...string) (string, error) {
+	return GetGitCommitHashFullContext(context.Background(), ref, paths...)
+}
+
+// GetGitCommitHashFullContext работает как GetGitCommitHashFull с контекстом
+func GetGitCommitHashFullContext(ctx context.Context, ref string, paths ...string) (string, error) {
+	return defaultRepo.CommitHashFull(ctx, ref, paths...)
+}
+
+// CommitHashFull работает как GetGitCommitHashFull для этого репозитория
+func (r *Repo) CommitHashFull(ctx context.Context, ref string, paths ...string) (string, error) {
+	if len(paths) > 0 {
+		return r.lastCommit(ctx, "failed to get Git commit hash", "%H", ref, paths)
+	}
+	if rr, ok := r.runner.(refReader); ok {
+		hash, err := rr.resolveRef(ctx, ref)
+		if err != nil {
+			return "", wrapGitError("failed to get Git commit hash", err)
+		}
+		return hash, nil
+	}
+
 	args := []string{"rev-parse", "HEAD"}
 	if ref != "" {
 		args = []string{"rev-parse", ref}
 	}
 
-	stdout, stderr, err := runGitCommand(args...)
+	stdout, err := r.run(ctx, args...)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Git commit hash: %v, %s", err, stderr)
+		return "", wrapGitError("failed to get Git commit hash", err)
 	}
 	return strings.TrimSpace(stdout), nil
 }
 
-func GetGitCommitHashShort(ref string) (string, error) {
+func GetGitCommitHashShort(ref string, paths ...string) (string, error) {
+	return GetGitCommitHashShortContext(context.Background(), ref, paths...)
+}
+
+// GetGitCommitHashShortContext работает как GetGitCommitHashShort с контекстом
+func GetGitCommitHashShortContext(ctx context.Context, ref string, paths ...string) (string, error) {
+	return defaultRepo.CommitHashShort(ctx, ref, paths...)
+}
+
+// CommitHashShort возвращает сокращенный хэш коммита ref или HEAD либо
+// последнего коммита, затрагивающего paths.
+// Без исполняемого git хэш всегда сокращается до 7 символов.
+func (r *Repo) CommitHashShort(ctx context.Context, ref string, paths ...string) (string, error) {
+	if len(paths) > 0 {
+		return r.lastCommit(ctx, "failed to get Git commit hash", "%h", ref, paths)
+	}
+	if _, ok := r.runner.(refReader); ok {
+		hash, err := r.CommitHashFull(ctx, ref)
+		if err != nil {
+			return "", err
+		}
+		return hash[:7], nil
+	}
+
 	args := []string{"rev-parse", "--short", "HEAD"}
 	if ref != "" {
 		args = []string{"rev-parse", "--short", ref}
 	}
 
-	stdout, stderr, err := runGitCommand(args...)
+	stdout, err := r.run(ctx, args...)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Git commit hash: %v, %s", err, stderr)
+		return "", wrapGitError("failed to get Git commit hash", err)
 	}
 	return strings.TrimSpace(stdout), nil
 }
 
 func GetGitBranchName() (string, error) {
-	stdout, stderr, err := runGitCommand("rev-parse", "--abbrev-ref", "HEAD")
+	return GetGitBranchNameContext(context.Background())
+}
+
+// GetGitBranchNameContext работает как GetGitBranchName с контекстом
+func GetGitBranchNameContext(ctx context.Context) (string, error) {
+	return defaultRepo.BranchName(ctx)
+}
+
+// BranchName возвращает имя текущей ветки или "HEAD", если HEAD отсоединен
+func (r *Repo) BranchName(ctx context.Context) (string, error) {
+	if rr, ok := r.runner.(refReader); ok {
+		branch, err := rr.branchName(ctx)
+		if err != nil {
+			return "", wrapGitError("failed to get Git branch name", err)
+		}
+		return branch, nil
+	}
+
+	stdout, err := r.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
-		return "", fmt.Errorf("failed to get Git branch name: %v, %s", err, stderr)
+		return "", wrapGitError("failed to get Git branch name", err)
 	}
 	return strings.TrimSpace(stdout), nil
 }
 
-func GetGitCommitDate(ref string) (time.Time, error) {
-	args := []string{"log", "-1", "--format=%cd", "--date=local"}
-	if ref != "" {
-		args = append(args, ref)
+func GetGitCommitDate(ref string, paths ...string) (time.Time, error) {
+	return GetGitCommitDateContext(context.Background(), ref, paths...)
+}
+
+// GetGitCommitDateContext работает как GetGitCommitDate с контекстом
+func GetGitCommitDateContext(ctx context.Context, ref string, paths ...string) (time.Time, error) {
+	return defaultRepo.CommitDate(ctx, ref, paths...)
+}
+
+// CommitDate возвращает дату коммита ref или HEAD либо последнего коммита,
+// затрагивающего paths
+func (r *Repo) CommitDate(ctx context.Context, ref string, paths ...string) (time.Time, error) {
+	if rr, ok := r.runner.(refReader); ok && len(paths) == 0 {
+		date, err := rr.commitTime(ctx, ref)
+		if err != nil {
+			return time.Time{}, wrapGitError("failed to get Git commit date", err)
+		}
+		return date, nil
 	}
 
-	stdout, stderr, err := runGitCommand(args...)
+	stdout, err := r.lastCommit(ctx, "failed to get Git commit date", "%cd", ref, paths)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to get Git commit date: %v, %s", err, stderr)
+		return time.Time{}, err
 	}
 
-	strDate := strings.TrimSpace(stdout)
-	date, err := time.Parse("Mon Jan 2 15:04:05 2006", strDate)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse Git commit date: %v", err)
+	fields := strings.Fields(stdout)
+	if len(fields) != 2 {
+		return time.Time{}, fmt.Errorf("failed to parse Git commit date %q", strings.TrimSpace(stdout))
 	}
-	return date, nil
+	return parseGitTime(fields[0], fields[1])
+}
+
+// GetGitChangelog получает журнал коммитов Git с учетом даты и ссылки.
+// Если заданы paths (pathspec git), в журнал попадают только коммиты,
+// затрагивающие их.
+func GetGitChangelog(since, ref string, paths ...string) (*Changelog, error) {
+	return GetGitChangelogContext(context.Background(), since, ref, paths...)
+}
+
+// GetGitChangelogContext работает как GetGitChangelog с контекстом
+func GetGitChangelogContext(ctx context.Context, since, ref string, paths ...string) (*Changelog, error) {
+	return defaultRepo.Changelog(ctx, since, ref, paths...)
 }
 
-// GetGitChangelog получает журнал коммитов Git с учетом даты и ссылки
-func GetGitChangelog(since, ref string) (*Changelog, error) {
-	var cmdArgs []string
-	cmdArgs = []string{"log", "--pretty=format:%h - %s - %an <%ae> - %ad", "--no-merges", "--date=iso"}
+// Changelog получает журнал коммитов с учетом даты, ссылки и путей.
+// Для репозитория без коммитов возвращается пустой журнал.
+func (r *Repo) Changelog(ctx context.Context, since, ref string, paths ...string) (*Changelog, error) {
+	return r.changelog(ctx, since, ref, paths, false)
+}
+
+// ChangelogStats работает как Changelog, но дополнительно заполняет Additions
+// и Deletions коммитов (git log --numstat), что заметно медленнее
+func (r *Repo) ChangelogStats(ctx context.Context, since, ref string, paths ...string) (*Changelog, error) {
+	return r.changelog(ctx, since, ref, paths, true)
+}
+
+func (r *Repo) changelog(ctx context.Context, since, ref string, paths []string, stats bool) (*Changelog, error) {
+	cmdArgs := commitLogArgs(stats)
 
 	if since != "" {
 		cmdArgs = append(cmdArgs, fmt.Sprintf("--since=%s", since))
@@ -144,58 +381,236 @@ func GetGitChangelog(since, ref string) (*Changelog, error) {
 	if ref != "" {
 		cmdArgs = append(cmdArgs, ref)
 	}
+	if len(paths) > 0 {
+		cmdArgs = append(append(cmdArgs, "--"), paths...)
+	}
+
+	return r.commitLog(ctx, "failed to get Git changelog", cmdArgs...)
+}
+
+// GetGitChangelogRange получает журнал коммитов from..to. Пустой from
+// означает все коммиты до to (первый релиз), пустой to - HEAD.
+// paths ограничивает журнал коммитами, затрагивающими эти пути.
+func GetGitChangelogRange(from, to string, paths ...string) (*Changelog, error) {
+	return GetGitChangelogRangeContext(context.Background(), from, to, paths...)
+}
+
+// GetGitChangelogRangeContext работает как GetGitChangelogRange с контекстом
+func GetGitChangelogRangeContext(ctx context.Context, from, to string, paths ...string) (*Changelog, error) {
+	return defaultRepo.ChangelogRange(ctx, from, to, paths...)
+}
+
+// ChangelogRange работает как GetGitChangelogRange для этого репозитория
+func (r *Repo) ChangelogRange(ctx context.Context, from, to string, paths ...string) (*Changelog, error) {
+	return r.changelogRange(ctx, from, to, paths, false)
+}
+
+// ChangelogRangeStats работает как ChangelogRange со статистикой строк, см. ChangelogStats
+func (r *Repo) ChangelogRangeStats(ctx context.Context, from, to string, paths ...string) (*Changelog, error) {
+	return r.changelogRange(ctx, from, to, paths, true)
+}
+
+func (r *Repo) changelogRange(ctx context.Context, from, to string, paths []string, stats bool) (*Changelog, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+
+	args := append(commitLogArgs(stats), rangeArg, "--")
+	return r.commitLog(ctx, fmt.Sprintf("failed to get Git changelog %s", rangeArg), append(args, paths...)...)
+}
 
-	output, stderr, err := runGitCommand(cmdArgs...)
+// commitLogArgs возвращает аргументы git log для parseCommitLog;
+// stats добавляет --numstat
+func commitLogArgs(stats bool) []string {
+	args := []string{"log", "--pretty=format:" + commitLogFormat, "--no-merges", "--date=raw"}
+	if stats {
+		args = append(args, "--numstat")
+	}
+	return args
+}
+
+// lastCommit выводит поля format (git log --date=raw) последнего коммита
+// до ref (по умолчанию HEAD), затрагивающего paths
+func (r *Repo) lastCommit(ctx context.Context, msg, format, ref string, paths []string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	args := append([]string{"log", "-1", "--format=" + format, "--date=raw", ref, "--"}, paths...)
+	stdout, err := r.run(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Git changelog: %v, stderr: %s", err, stderr)
+		return "", wrapGitError(msg, err)
 	}
+	out := strings.TrimSpace(stdout)
+	if out == "" {
+		return "", fmt.Errorf("%s: no commits touch %s", msg, strings.Join(paths, " "))
+	}
+	return out, nil
+}
+
+// commitLog запускает git log в формате commitLogFormat и строит журнал.
+// Для репозитория без коммитов возвращается пустой журнал.
+func (r *Repo) commitLog(ctx context.Context, msg string, args ...string) (*Changelog, error) {
+	output, err := r.run(ctx, args...)
+	if errors.Is(err, ErrNoCommits) {
+		return &Changelog{}, nil
+	}
+	if err != nil {
+		return nil, wrapGitError(msg, err)
+	}
+
+	commits, err := parseCommitLog(output)
+	if err != nil {
+		return nil, err
+	}
+	return newChangelog(commits), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	entries := make([]string, len(lines))
-	commits := make([]CommitDetails, len(lines))
+// commitLogFormat - формат git log для parseCommitLog: поля разделены NUL,
+// записи начинаются символом RS. Тело идет последним, так как может содержать
+// что угодно, а после него git выводит строки --numstat, если они запрошены.
+const commitLogFormat = "%x1e%H%x00%h%x00%P%x00%an%x00%ae%x00%ad%x00%cn%x00%ce%x00%cd%x00%(trailers:only,unfold)%x00%s%x00%b%x00"
 
-	for i, line := range lines {
-		entries[i] = line
+const commitLogFields = 13
 
-		parts := strings.SplitN(line, " - ", 4)
-		if len(parts) < 4 {
+// parseCommitLog разбирает вывод git log --date=raw в формате commitLogFormat
+func parseCommitLog(out string) ([]CommitDetails, error) {
+	var commits []CommitDetails
+	for _, record := range splitCommitRecords(out) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
 			continue
 		}
+		f := strings.SplitN(record, "\x00", commitLogFields)
+		if len(f) != commitLogFields {
+			return nil, fmt.Errorf("failed to parse Git log record %q", record)
+		}
 
-		authorPart := parts[2]
-		emailStart := strings.Index(authorPart, "<")
-		emailEnd := strings.Index(authorPart, ">")
-		var email string
-		if emailStart != -1 && emailEnd != -1 && emailEnd > emailStart {
-			email = authorPart[emailStart+1 : emailEnd]
-		} else {
-			email = "unknown"
+		authorDate, err := parseRawGitDate(f[5])
+		if err != nil {
+			return nil, err
 		}
-		var author string
-		if emailStart != -1 {
-			author = strings.TrimSpace(authorPart[:emailStart-1])
-		} else {
-			author = strings.TrimSpace(authorPart)
+		committerDate, err := parseRawGitDate(f[8])
+		if err != nil {
+			return nil, err
 		}
 
-		commits[i] = CommitDetails{
-			Hash:    parts[0],
-			Message: parts[1],
-			Author:  author,
-			Email:   email,
-			Date:    parts[3],
+		additions, deletions := parseNumstat(f[12])
+		commits = append(commits, CommitDetails{
+			Hash:           f[1],
+			FullHash:       f[0],
+			Parents:        strings.Fields(f[2]),
+			Message:        f[10],
+			Body:           strings.TrimSpace(f[11]),
+			Trailers:       parseTrailers(f[9]),
+			Author:         f[3],
+			Email:          f[4],
+			Date:           authorDate.Format(gitISODate),
+			AuthorDate:     authorDate,
+			Committer:      f[6],
+			CommitterEmail: f[7],
+			CommitterDate:  committerDate,
+			Additions:      additions,
+			Deletions:      deletions,
+		})
+	}
+	return commits, nil
+}
+
+// splitCommitRecords делит вывод git log на записи. Символ RS может встретиться
+// и в теле коммита, поэтому началом записи считается только RS, за которым
+// следуют полный хэш и NUL.
+func splitCommitRecords(out string) []string {
+	var records []string
+	start := -1
+	for i := 0; i < len(out); i++ {
+		if out[i] != '\x1e' || len(out) < i+42 || out[i+41] != 0 || !isObjectHash(out[i+1:i+41]) {
+			continue
 		}
+		if start >= 0 {
+			records = append(records, out[start:i])
+		}
+		start = i + 1
+	}
+	if start >= 0 {
+		records = append(records, out[start:])
 	}
+	return records
+}
 
-	return &Changelog{Entries: entries, Commits: commits}, nil
+// parseNumstat суммирует добавленные и удаленные строки из вывода --numstat.
+// Для двоичных файлов git выводит "-" вместо чисел, они не учитываются.
+func parseNumstat(out string) (additions, deletions int) {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			additions += n
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			deletions += n
+		}
+	}
+	return additions, deletions
+}
+
+// gitISODate - формат даты git --date=iso
+const gitISODate = "2006-01-02 15:04:05 -0700"
+
+// parseTrailers разбирает вывод %(trailers:only,unfold)
+func parseTrailers(out string) []Trailer {
+	var trailers []Trailer
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		trailers = append(trailers, Trailer{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return trailers
+}
+
+// newChangelog строит журнал и строки Entries в прежнем формате
+// "<hash> - <subject> - <author> <<email>> - <date>"
+func newChangelog(commits []CommitDetails) *Changelog {
+	cl := &Changelog{Commits: commits}
+	for _, c := range commits {
+		cl.Entries = append(cl.Entries, fmt.Sprintf("%s - %s - %s <%s> - %s", c.Hash, c.Message, c.Author, c.Email, c.Date))
+	}
+	cl.ExtractReferences(nil)
+	return cl
+}
+
+func (cl *Changelog) clone() *Changelog {
+	return &Changelog{
+		Entries:  append([]string(nil), cl.Entries...),
+		Commits:  append([]CommitDetails(nil), cl.Commits...),
+		patterns: cl.patterns,
+	}
 }
 
 func (cl *Changelog) ToMarkdown() string {
 	var sb strings.Builder
 	sb.WriteString("## Changelog\n\n")
 	for _, entry := range cl.Entries {
-		sb.WriteString(fmt.Sprintf("- %s\n", entry))
+		sb.WriteString(fmt.Sprintf("- %s\n", markdownReferences(entry, cl.patterns)))
+	}
+	return sb.String()
+}
+
+// ToHTML выводит журнал списком HTML со ссылками на задачи
+func (cl *Changelog) ToHTML() string {
+	var sb strings.Builder
+	sb.WriteString("<h2>Changelog</h2>\n<ul>\n")
+	for _, entry := range cl.Entries {
+		fmt.Fprintf(&sb, "<li>%s</li>\n", htmlReferences(entry, cl.patterns))
 	}
+	sb.WriteString("</ul>\n")
 	return sb.String()
 }
 
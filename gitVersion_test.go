@@ -0,0 +1,152 @@
+package mkversions
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPrepareGitCalls(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.commit("a.txt", "a\n", "feat: a")
+	repo.git("tag", "v1.0.0")
+	repo.commit("b.txt", "b\n", "fix: b")
+
+	var calls []string
+	runner := &ExecGitRunner{Dir: repo.dir}
+	counting := gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		calls = append(calls, args[0])
+		return runner.Run(ctx, args...)
+	})
+
+	info, err := NewInfoContext(context.Background(), "", "dev", "me", WithGitRunner(counting))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "1.0.0-1-g"+info.GITInfo.CommitHashShort || len(info.GITInfo.Changelog.Commits) != 1 {
+		t.Errorf("Version = %q, %d changelog commits", info.Version, len(info.GITInfo.Changelog.Commits))
+	}
+	// Число вызовов описано в документации PrepareGitContext
+	if want := []string{"status", "log", "describe", "tag", "log"}; !slices.Equal(calls, want) {
+		t.Errorf("git calls = %q, want %q", calls, want)
+	}
+}
+
+// logRecord собирает запись git log в формате commitLogFormat
+func logRecord(hash, parents, trailers, subject, body, numstat string) string {
+	fields := []string{hash, hash[:7], parents, "Ann", "ann@example.com", "1700000000 +0300",
+		"Com", "com@example.com", "1700003600 +0000", trailers, subject, body}
+	return "\x1e" + strings.Join(fields, "\x00") + "\x00" + numstat
+}
+
+func TestParseCommitLog(t *testing.T) {
+	const (
+		h1 = "1111111111111111111111111111111111111111"
+		h2 = "2222222222222222222222222222222222222222"
+		h3 = "3333333333333333333333333333333333333333"
+		h4 = "4444444444444444444444444444444444444444"
+	)
+	out := strings.Join([]string{
+		logRecord(h1, h2, "", "fix: empty body", "", ""),
+		// Тело содержит RS, пустые строки и строки, похожие на трейлеры;
+		// NUL в сообщении git не сохраняет
+		logRecord(h2, h3, "Signed-off-by: Ann <ann@example.com>\n", "feat: separators",
+			"line \x1e with RS\n\nkey: value\n\x1e\n\nSigned-off-by: Ann <ann@example.com>\n", ""),
+		logRecord(h3, h1+" "+h2+" "+h4, "", "Merge branches 'a' and 'b'", "", ""),
+		logRecord(h4, "", "", "feat: stats", "", "\n1\t2\ta.go\n-\t-\tlogo.png\n3\t0\tb.go\n"),
+	}, "\n")
+
+	commits, err := parseCommitLog(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 4 {
+		t.Fatalf("got %d commits, want 4", len(commits))
+	}
+
+	if c := commits[0]; c.FullHash != h1 || c.Hash != h1[:7] || c.Body != "" || c.Trailers != nil || !slices.Equal(c.Parents, []string{h2}) {
+		t.Errorf("empty body commit = %+v", c)
+	}
+	if c := commits[0]; c.Author != "Ann" || c.Email != "ann@example.com" || c.Committer != "Com" || c.CommitterEmail != "com@example.com" {
+		t.Errorf("author, committer = %q <%q>, %q <%q>", c.Author, c.Email, c.Committer, c.CommitterEmail)
+	}
+	if c := commits[0]; c.Date != "2023-11-15 01:13:20 +0300" || c.CommitterDate.Unix() != 1700003600 {
+		t.Errorf("dates = %q, %v", c.Date, c.CommitterDate)
+	}
+
+	wantBody := "line \x1e with RS\n\nkey: value\n\x1e\n\nSigned-off-by: Ann <ann@example.com>"
+	if c := commits[1]; c.Message != "feat: separators" || c.Body != wantBody {
+		t.Errorf("separators commit: message %q, body %q", c.Message, c.Body)
+	}
+	if want := []Trailer{{"Signed-off-by", "Ann <ann@example.com>"}}; !slices.Equal(commits[1].Trailers, want) {
+		t.Errorf("Trailers = %+v, want %+v", commits[1].Trailers, want)
+	}
+
+	if want := []string{h1, h2, h4}; !slices.Equal(commits[2].Parents, want) {
+		t.Errorf("merge Parents = %q, want %q", commits[2].Parents, want)
+	}
+	if c := commits[3]; len(c.Parents) != 0 || c.Additions != 4 || c.Deletions != 2 {
+		t.Errorf("root commit: parents %q, +%d -%d", c.Parents, c.Additions, c.Deletions)
+	}
+}
+
+func TestParseCommitLogInvalid(t *testing.T) {
+	for _, out := range []string{
+		"\x1e1111111111111111111111111111111111111111\x00short",
+		logRecord("1111111111111111111111111111111111111111", "", "", "s", "", "")[:60] + "\x00x\x00y",
+	} {
+		if commits, err := parseCommitLog(out); err == nil {
+			t.Errorf("parseCommitLog(%q) = %+v, want error", out, commits)
+		}
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	out := "Signed-off-by: Ann <ann@example.com>\n" +
+		// git объединяет продолжение трейлера в одну строку (unfold)
+		"Reviewed-by: Bob <bob@example.com> and a very long comment\n" +
+		"Link: https://example.com/issues/1\n" +
+		"\n"
+	want := []Trailer{
+		{"Signed-off-by", "Ann <ann@example.com>"},
+		{"Reviewed-by", "Bob <bob@example.com> and a very long comment"},
+		{"Link", "https://example.com/issues/1"},
+	}
+	if got := parseTrailers(out); !slices.Equal(got, want) {
+		t.Errorf("parseTrailers = %+v, want %+v", got, want)
+	}
+	if got := parseTrailers(""); got != nil {
+		t.Errorf("parseTrailers(\"\") = %+v, want nil", got)
+	}
+}
+
+// TestChangelogGitLog проверяет разбор настоящего вывода git log
+func TestChangelogGitLog(t *testing.T) {
+	repo := newTestGitRepo(t)
+	first := repo.commit("a.txt", "a\n", "feat: a")
+	repo.commit("b.txt", "b\n", "fix: b\n\nline \x1e with RS\n\nReviewed-by: Bob <bob@example.com>\n  and a folded comment\nSigned-off-by: Ann <ann@example.com>")
+	repo.commit("c.txt", "c\n", "docs: c")
+
+	cl, err := NewRepo(&ExecGitRunner{Dir: repo.dir}).ChangelogRange(context.Background(), first, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cl.Commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(cl.Commits))
+	}
+	c := cl.Commits[1]
+	if c.Message != "fix: b" || !strings.HasPrefix(c.Body, "line \x1e with RS\n\nReviewed-by:") || !slices.Equal(c.Parents, []string{first}) {
+		t.Errorf("commit = %q, body %q, parents %q", c.Message, c.Body, c.Parents)
+	}
+	want := []Trailer{
+		{"Reviewed-by", "Bob <bob@example.com> and a folded comment"},
+		{"Signed-off-by", "Ann <ann@example.com>"},
+	}
+	if !slices.Equal(c.Trailers, want) {
+		t.Errorf("Trailers = %+v, want %+v", c.Trailers, want)
+	}
+	if cl.Commits[0].Body != "" || cl.Commits[0].Trailers != nil {
+		t.Errorf("empty body commit: body %q, trailers %+v", cl.Commits[0].Body, cl.Commits[0].Trailers)
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/SHEP4RDO/mkversions
 
-go 1.20
+go 1.21
@@ -0,0 +1,487 @@
+package mkversions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// unreleasedVersion - заголовок раздела с еще не выпущенными изменениями
+const unreleasedVersion = "Unreleased"
+
+const defaultKeepAChangelogPreamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+
+`
+
+var (
+	releaseHeadingRe = regexp.MustCompile(`^## \[?([^\]\s]+)\]?(?:\s+-\s+(\S+))?(\s+\[YANKED\])?\s*$`)
+	linkDefinitionRe = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)\s*$`)
+	compareLinkRe    = regexp.MustCompile(`^(.*/compare/)(.+)\.\.\.HEAD$`)
+)
+
+// KeepAChangelog - файл CHANGELOG.md в формате Keep a Changelog.
+// Разделы, которые не изменялись, записываются обратно без изменений.
+type KeepAChangelog struct {
+	// Preamble - текст до первого раздела версии
+	Preamble string
+	Releases []*ChangelogRelease
+	// Links - ссылки вида "[1.0.0]: https://..." в конце файла
+	Links []ChangelogLink
+
+	linksRaw      string
+	linksModified bool
+	// blankAfterHeading - ставить ли пустую строку после "### Added"
+	blankAfterHeading bool
+}
+
+// ChangelogRelease - раздел одной версии или Unreleased
+type ChangelogRelease struct {
+	Version string
+	Date    string
+	Yanked  bool
+	// Intro - текст между заголовком версии и первым подразделом
+	Intro    string
+	Sections []*ChangelogReleaseSection
+
+	raw      string
+	modified bool
+}
+
+// ChangelogReleaseSection - подраздел версии, например "### Added".
+// Прочитанный из файла подраздел записывается построчно как был; новые
+// пункты Entries вставляются после последнего пункта списка.
+type ChangelogReleaseSection struct {
+	Title string
+	// Intro - текст перед первым пунктом списка
+	Intro   string
+	Entries []string
+	// Notes - текст после первого пункта списка, не относящийся к пунктам
+	Notes string
+
+	// raw - строки подраздела из файла, начиная с заголовка
+	raw []string
+	// entriesEnd - индекс в raw после последней строки пунктов списка
+	entriesEnd int
+	// parsed - подраздел в прочитанном виде, чтобы заметить изменения
+	parsed *ChangelogReleaseSection
+}
+
+// ChangelogLink - определение ссылки Markdown в конце файла
+type ChangelogLink struct {
+	Name string
+	URL  string
+}
+
+// ReadKeepAChangelog читает CHANGELOG.md; для отсутствующего файла
+// возвращается новый журнал с разделом Unreleased
+func ReadKeepAChangelog(path string) (*KeepAChangelog, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ParseKeepAChangelog([]byte(defaultKeepAChangelogPreamble + "## [" + unreleasedVersion + "]\n"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changelog: %v", err)
+	}
+	return ParseKeepAChangelog(data)
+}
+
+// ParseKeepAChangelog разбирает журнал в формате Keep a Changelog
+func ParseKeepAChangelog(data []byte) (*KeepAChangelog, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	c := &KeepAChangelog{}
+
+	// Определения ссылок в конце файла
+	end := len(lines)
+	linksStart := end
+	for i := end - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if !linkDefinitionRe.MatchString(line) {
+			break
+		}
+		linksStart = i
+	}
+	for linksStart < end && strings.TrimSpace(lines[linksStart]) == "" {
+		linksStart++
+	}
+	for _, line := range lines[linksStart:] {
+		if m := linkDefinitionRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			c.Links = append(c.Links, ChangelogLink{Name: m[1], URL: m[2]})
+		}
+	}
+	c.linksRaw = strings.Join(lines[linksStart:], "")
+	lines = lines[:linksStart]
+
+	var current *ChangelogRelease
+	var preamble, body []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.raw += strings.Join(body, "")
+		current.parseBody(body, &c.blankAfterHeading)
+		c.Releases = append(c.Releases, current)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			if current == nil {
+				preamble = append(preamble, line)
+			} else {
+				body = append(body, line)
+			}
+			continue
+		}
+		flush()
+		m := releaseHeadingRe.FindStringSubmatch(strings.TrimRight(line, "\n"))
+		if m == nil {
+			return nil, fmt.Errorf("malformed changelog release heading %q", strings.TrimSpace(line))
+		}
+		current = &ChangelogRelease{Version: m[1], Date: m[2], Yanked: m[3] != "", raw: line}
+		body = nil
+	}
+	flush()
+	c.Preamble = strings.Join(preamble, "")
+	return c, nil
+}
+
+// parseBody разбирает подразделы "### " и пункты списков "- " или "* "
+func (r *ChangelogRelease) parseBody(lines []string, blankAfterHeading *bool) {
+	var intro []string
+	var section *ChangelogReleaseSection
+	var sectionIntro, sectionNotes []string
+	afterHeading := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\n")
+		if strings.HasPrefix(trimmed, "### ") {
+			section.finishParse(sectionIntro, sectionNotes)
+			section = &ChangelogReleaseSection{Title: strings.TrimSpace(trimmed[4:]), raw: []string{line}}
+			sectionIntro, sectionNotes = nil, nil
+			r.Sections = append(r.Sections, section)
+			afterHeading = true
+			continue
+		}
+		if section == nil {
+			intro = append(intro, line)
+			continue
+		}
+
+		section.raw = append(section.raw, line)
+		switch {
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			section.Entries = append(section.Entries, strings.TrimSpace(trimmed[2:]))
+			section.entriesEnd = len(section.raw)
+		case len(section.Entries) > 0 && strings.TrimSpace(trimmed) != "" &&
+			(strings.HasPrefix(trimmed, " ") || strings.HasPrefix(trimmed, "\t")) &&
+			strings.TrimSpace(strings.Join(section.raw[section.entriesEnd:len(section.raw)-1], "")) == "":
+			// Продолжение пункта списка, возможно после пустой строки
+			section.Entries[len(section.Entries)-1] += "\n" + strings.TrimSpace(trimmed)
+			section.entriesEnd = len(section.raw)
+		case len(section.Entries) == 0:
+			sectionIntro = append(sectionIntro, line)
+		default:
+			sectionNotes = append(sectionNotes, line)
+		}
+		if afterHeading {
+			if strings.TrimSpace(trimmed) == "" {
+				*blankAfterHeading = true
+			}
+			afterHeading = false
+		}
+	}
+	section.finishParse(sectionIntro, sectionNotes)
+	r.Intro = strings.TrimSpace(strings.Join(intro, ""))
+}
+
+// finishParse заполняет Intro и Notes и запоминает прочитанный подраздел
+func (s *ChangelogReleaseSection) finishParse(intro, notes []string) {
+	if s == nil {
+		return
+	}
+	s.Intro = strings.TrimSpace(strings.Join(intro, ""))
+	s.Notes = strings.TrimSpace(strings.Join(notes, ""))
+	s.parsed = &ChangelogReleaseSection{Title: s.Title, Intro: s.Intro, Entries: slices.Clone(s.Entries), Notes: s.Notes}
+}
+
+// Release возвращает раздел версии или nil
+func (c *KeepAChangelog) Release(version string) *ChangelogRelease {
+	for _, r := range c.Releases {
+		if strings.EqualFold(r.Version, version) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Section возвращает подраздел с заголовком title, создавая его при необходимости
+func (r *ChangelogRelease) Section(title string) *ChangelogReleaseSection {
+	for _, s := range r.Sections {
+		if strings.EqualFold(s.Title, title) {
+			return s
+		}
+	}
+	s := &ChangelogReleaseSection{Title: title}
+	r.Sections = append(r.Sections, s)
+	r.modified = true
+	return s
+}
+
+// KeepAChangelogGroups сопоставляет типы Conventional Commits разделам
+// Keep a Changelog. Коммиты других типов в журнал не попадают.
+func KeepAChangelogGroups() *ChangelogGroups {
+	return &ChangelogGroups{
+		Titles: map[string]string{
+			"feat":     "Added",
+			"fix":      "Fixed",
+			"perf":     "Changed",
+			"refactor": "Changed",
+			"revert":   "Removed",
+			"security": "Security",
+		},
+		Order: []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"},
+	}
+}
+
+// AddRelease добавляет раздел версии version с датой date. Пункты из
+// Unreleased переносятся в новую версию, к ним добавляются коммиты changes,
+// сгруппированные по KeepAChangelogGroups. tag - имя тега версии для
+// обновления ссылок сравнения вида .../compare/v1.0.0...HEAD.
+func (c *KeepAChangelog) AddRelease(version string, date time.Time, changes *Changelog, tag string) (*ChangelogRelease, error) {
+	if version == "" {
+		return nil, fmt.Errorf("failed to add changelog release: empty version")
+	}
+	if c.Release(version) != nil {
+		return nil, fmt.Errorf("changelog already contains release %s", version)
+	}
+
+	release := &ChangelogRelease{Version: version, Date: date.Format("2006-01-02"), modified: true}
+
+	insertAt := 0
+	if unreleased := c.Release(unreleasedVersion); unreleased != nil {
+		for i, r := range c.Releases {
+			if r == unreleased {
+				insertAt = i + 1
+			}
+		}
+		release.Sections = unreleased.Sections
+		unreleased.Sections = nil
+		unreleased.modified = true
+	}
+
+	if changes != nil {
+		grouped := changes.Group(KeepAChangelogGroups())
+		for _, s := range grouped.Sections {
+			section := release.Section(s.Title)
+			for _, commit := range s.Commits {
+				entry := commit.markdownLine(grouped.patterns)
+				if commit.Conventional != nil && commit.Conventional.Breaking {
+					entry = "**BREAKING:** " + entry
+				}
+				if !slices.Contains(section.Entries, entry) {
+					section.Entries = append(section.Entries, entry)
+				}
+			}
+		}
+	}
+	release.sortSections(KeepAChangelogGroups().Order)
+
+	c.Releases = append(c.Releases[:insertAt], append([]*ChangelogRelease{release}, c.Releases[insertAt:]...)...)
+	c.updateLinks(version, tag)
+	return release, nil
+}
+
+// sortSections упорядочивает подразделы по order, сохраняя порядок остальных
+func (r *ChangelogRelease) sortSections(order []string) {
+	var sorted []*ChangelogReleaseSection
+	used := map[*ChangelogReleaseSection]bool{}
+	for _, title := range order {
+		for _, s := range r.Sections {
+			if !used[s] && strings.EqualFold(s.Title, title) {
+				sorted = append(sorted, s)
+				used[s] = true
+			}
+		}
+	}
+	for _, s := range r.Sections {
+		if !used[s] {
+			sorted = append(sorted, s)
+		}
+	}
+	r.Sections = sorted
+}
+
+// updateLinks переводит ссылку [Unreleased] на новый тег и добавляет ссылку версии
+func (c *KeepAChangelog) updateLinks(version, tag string) {
+	if tag == "" {
+		return
+	}
+	for i, link := range c.Links {
+		if !strings.EqualFold(link.Name, unreleasedVersion) {
+			continue
+		}
+		m := compareLinkRe.FindStringSubmatch(link.URL)
+		if m == nil {
+			return
+		}
+		c.Links[i].URL = m[1] + tag + "...HEAD"
+		added := ChangelogLink{Name: version, URL: m[1] + m[2] + "..." + tag}
+		c.Links = append(c.Links[:i+1], append([]ChangelogLink{added}, c.Links[i+1:]...)...)
+		c.linksModified = true
+		return
+	}
+}
+
+// Bytes возвращает содержимое файла
+func (c *KeepAChangelog) Bytes() []byte {
+	var sb strings.Builder
+	sb.WriteString(c.Preamble)
+	for _, r := range c.Releases {
+		if !r.modified {
+			sb.WriteString(r.raw)
+			continue
+		}
+		if s := sb.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+			if !strings.HasSuffix(s, "\n") {
+				sb.WriteString("\n")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(r.render(c.blankAfterHeading))
+	}
+
+	if c.linksModified {
+		var links strings.Builder
+		for _, link := range c.Links {
+			fmt.Fprintf(&links, "[%s]: %s\n", link.Name, link.URL)
+		}
+		c.linksRaw = links.String()
+		c.linksModified = false
+	}
+	if c.linksRaw != "" {
+		if s := sb.String(); !strings.HasSuffix(s, "\n\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(c.linksRaw)
+	}
+
+	out := strings.TrimRight(sb.String(), "\n") + "\n"
+	return []byte(out)
+}
+
+// render строит Markdown раздела версии
+func (r *ChangelogRelease) render(blankAfterHeading bool) string {
+	var sb strings.Builder
+	if strings.EqualFold(r.Version, unreleasedVersion) {
+		fmt.Fprintf(&sb, "## [%s]\n\n", r.Version)
+	} else {
+		fmt.Fprintf(&sb, "## [%s] - %s", r.Version, r.Date)
+		if r.Yanked {
+			sb.WriteString(" [YANKED]")
+		}
+		sb.WriteString("\n\n")
+	}
+	if r.Intro != "" {
+		sb.WriteString(r.Intro)
+		sb.WriteString("\n\n")
+	}
+	for _, s := range r.Sections {
+		if len(s.Entries) == 0 && s.Intro == "" && s.Notes == "" {
+			continue
+		}
+		sb.WriteString(s.render(blankAfterHeading))
+	}
+	return sb.String()
+}
+
+// render строит Markdown подраздела, заканчивающийся пустой строкой
+func (s *ChangelogReleaseSection) render(blankAfterHeading bool) string {
+	var sb strings.Builder
+	if s.unchanged() {
+		// Строки из файла сохраняются, новые пункты дописываются в конец списка
+		insertAt := s.entriesEnd
+		if insertAt == 0 {
+			insertAt = len(s.raw)
+			for insertAt > 1 && strings.TrimSpace(s.raw[insertAt-1]) == "" {
+				insertAt--
+			}
+		}
+		sb.WriteString(strings.Join(s.raw[:insertAt], ""))
+		added := s.Entries[len(s.parsed.Entries):]
+		if len(added) > 0 && s.entriesEnd == 0 && (insertAt > 1 || blankAfterHeading) {
+			sb.WriteString("\n")
+		}
+		for _, entry := range added {
+			fmt.Fprintf(&sb, "- %s\n", strings.ReplaceAll(entry, "\n", "\n  "))
+		}
+		sb.WriteString(strings.Join(s.raw[insertAt:], ""))
+		return strings.TrimRight(sb.String(), "\n") + "\n\n"
+	}
+
+	fmt.Fprintf(&sb, "### %s\n", s.Title)
+	if blankAfterHeading {
+		sb.WriteString("\n")
+	}
+	if s.Intro != "" {
+		sb.WriteString(s.Intro)
+		sb.WriteString("\n\n")
+	}
+	for _, entry := range s.Entries {
+		fmt.Fprintf(&sb, "- %s\n", strings.ReplaceAll(entry, "\n", "\n  "))
+	}
+	if s.Notes != "" {
+		sb.WriteString("\n")
+		sb.WriteString(s.Notes)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// unchanged сообщает, что подраздел прочитан из файла и с тех пор в него
+// только добавлялись пункты
+func (s *ChangelogReleaseSection) unchanged() bool {
+	p := s.parsed
+	return p != nil && s.Title == p.Title && s.Intro == p.Intro && s.Notes == p.Notes &&
+		len(s.Entries) >= len(p.Entries) && slices.Equal(s.Entries[:len(p.Entries)], p.Entries)
+}
+
+// WriteFile записывает журнал в файл
+func (c *KeepAChangelog) WriteFile(path string) error {
+	if err := os.WriteFile(path, c.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write changelog: %v", err)
+	}
+	return nil
+}
+
+// UpdateChangelogFile добавляет в CHANGELOG.md раздел для Version с датой
+// BuildDate из коммитов Changelog (по умолчанию - с предыдущего релизного тега)
+func (info *Info) UpdateChangelogFile(path string) error {
+	c, err := ReadKeepAChangelog(path)
+	if err != nil {
+		return err
+	}
+	var changes *Changelog
+	if info.GITInfo != nil {
+		changes = info.GITInfo.Changelog
+	}
+	if _, err := c.AddRelease(info.Version, info.BuildDate, changes, info.TagPrefix()+info.Version); err != nil {
+		return err
+	}
+	return c.WriteFile(path)
+}
@@ -0,0 +1,121 @@
+package mkversions
+
+import (
+	"testing"
+	"time"
+)
+
+const testKeepAChangelog = `# Changelog
+
+## [Unreleased]
+
+### Added
+
+First paragraph of notes.
+
+Second paragraph of notes.
+
+- Existing entry
+  continued on the next line
+
+- Loose entry
+
+  with a second paragraph
+
+Prose written after the list.
+
+### Fixed
+
+Only prose here.
+
+## [1.0.0] - 2024-01-02
+
+### Added
+
+- Initial release
+
+Closing remark.
+
+[Unreleased]: https://example.com/compare/v1.0.0...HEAD
+[1.0.0]: https://example.com/releases/v1.0.0
+`
+
+func TestKeepAChangelogRoundTrip(t *testing.T) {
+	c, err := ParseKeepAChangelog([]byte(testKeepAChangelog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(c.Bytes()); got != testKeepAChangelog {
+		t.Errorf("unmodified changelog changed:\n%s", got)
+	}
+
+	added := c.Release(unreleasedVersion).Section("Added")
+	if added.Intro != "First paragraph of notes.\n\nSecond paragraph of notes." {
+		t.Errorf("Intro = %q", added.Intro)
+	}
+	if added.Notes != "Prose written after the list." {
+		t.Errorf("Notes = %q", added.Notes)
+	}
+	if len(added.Entries) != 2 || added.Entries[1] != "Loose entry\nwith a second paragraph" {
+		t.Errorf("Entries = %q", added.Entries)
+	}
+}
+
+func TestKeepAChangelogAddReleaseKeepsProse(t *testing.T) {
+	c, err := ParseKeepAChangelog([]byte(testKeepAChangelog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := &Changelog{Commits: []CommitDetails{
+		{Hash: "abc1234", Message: "feat: new thing"},
+		{Hash: "def5678", Message: "fix: broken thing"},
+	}}
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := c.AddRelease("1.1.0", date, changes, "v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Changelog
+
+## [Unreleased]
+
+## [1.1.0] - 2024-03-04
+
+### Added
+
+First paragraph of notes.
+
+Second paragraph of notes.
+
+- Existing entry
+  continued on the next line
+
+- Loose entry
+
+  with a second paragraph
+- new thing (abc1234)
+
+Prose written after the list.
+
+### Fixed
+
+Only prose here.
+
+- broken thing (def5678)
+
+## [1.0.0] - 2024-01-02
+
+### Added
+
+- Initial release
+
+Closing remark.
+
+[Unreleased]: https://example.com/compare/v1.1.0...HEAD
+[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0
+[1.0.0]: https://example.com/releases/v1.0.0
+`
+	if got := string(c.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package mkversions
+
+import (
+	"strings"
+	"time"
+)
+
+// PackagePath - путь импорта пакета, используемый в -X флагах по умолчанию
+const PackagePath = "github.com/SHEP4RDO/mkversions"
+
+// Значения, задаваемые при компоновке:
+//
+//	go build -ldflags "-X github.com/SHEP4RDO/mkversions.version=1.2.3"
+//
+// Значения относятся к программе, в которую встроен пакет, а не к
+// репозиторию, для которого строится Info. Поэтому они применяются только
+// опцией WithLinkerVars и в NewInfoFromBuildInfo.
+var (
+	version   string
+	commit    string
+	branch    string
+	buildDate string
+)
+
+// WithLinkerVars переносит в Info непустые значения, заданные через -ldflags.
+// Опции после нее имеют приоритет. Используйте ее только для Info о самой программе.
+func WithLinkerVars() Option {
+	return applyLinkerVars
+}
+
+// applyLinkerVars переносит в Info значения, заданные через -ldflags.
+// Ветка не становится ссылкой для запросов git, в отличие от WithBranchName.
+func applyLinkerVars(info *Info) {
+	if version != "" {
+		info.Version = version
+	}
+	if commit != "" {
+		WithCommitHash(commit)(info)
+	}
+	if branch != "" {
+		info.GITInfo.BranchName = branch
+	}
+	if buildDate != "" {
+		if date, err := time.Parse(time.RFC3339, buildDate); err == nil {
+			info.BuildDate = date
+		}
+	}
+	if version != "" || commit != "" || buildDate != "" {
+		info.DetailedVersion = info.detailedVersion()
+	}
+}
+
+// LDFlags возвращает -X флаги компоновщика, воспроизводящие текущий Info.
+// Если pkgPath пуст, используется PackagePath.
+func (info *Info) LDFlags(pkgPath string) string {
+	if pkgPath == "" {
+		pkgPath = PackagePath
+	}
+
+	var flags []string
+	add := func(name, value string) {
+		if value == "" || value == "unknown" {
+			return
+		}
+		flags = append(flags, "-X "+quoteLDFlag(pkgPath+"."+name+"="+value))
+	}
+
+	add("version", info.Version)
+	if info.GITInfo != nil {
+		add("commit", info.GITInfo.CommitHash)
+		add("branch", info.GITInfo.BranchName)
+	}
+	if !info.BuildDate.IsZero() {
+		add("buildDate", info.BuildDate.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(flags, " ")
+}
+
+// quoteLDFlag заключает значение в кавычки, если go build разобьет его на части
+func quoteLDFlag(s string) string {
+	if !strings.ContainsAny(s, " \t\n\r'\"") {
+		return s
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return `"` + s + `"`
+}
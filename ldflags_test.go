@@ -0,0 +1,66 @@
+package mkversions
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// setLinkerVars подменяет значения -X флагов на время теста
+func setLinkerVars(t *testing.T, v, c, b, d string) {
+	old := [4]string{version, commit, branch, buildDate}
+	version, commit, branch, buildDate = v, c, b, d
+	t.Cleanup(func() { version, commit, branch, buildDate = old[0], old[1], old[2], old[3] })
+}
+
+func TestLinkerVarsDoNotLeakIntoOtherRepo(t *testing.T) {
+	repo := newTestGitRepo(t)
+	hash := repo.commit("a.txt", "a\n", "feat: first")
+	repo.git("tag", "v0.3.0")
+
+	setLinkerVars(t, "9.9.9", "0123456789abcdef0123456789abcdef01234567", "linked-branch", "2020-01-02T03:04:05Z")
+	info, err := NewInfoContext(context.Background(), "", "dev", "me",
+		WithGitRunner(&ExecGitRunner{Dir: repo.dir}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != "0.3.0" {
+		t.Errorf("Version = %q, want 0.3.0 from the repository tag", info.Version)
+	}
+	if info.GITInfo.CommitHash != hash {
+		t.Errorf("CommitHash = %q, want %q", info.GITInfo.CommitHash, hash)
+	}
+	if info.GITInfo.BranchName != "main" {
+		t.Errorf("BranchName = %q, want main", info.GITInfo.BranchName)
+	}
+	if info.BuildDate.Year() == 2020 {
+		t.Errorf("BuildDate = %v taken from linker vars", info.BuildDate)
+	}
+}
+
+func TestWithLinkerVars(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.commit("a.txt", "a\n", "feat: first")
+
+	setLinkerVars(t, "9.9.9", "0123456789abcdef0123456789abcdef01234567", "linked-branch", "2020-01-02T03:04:05Z")
+	info, err := NewInfoContext(context.Background(), "", "dev", "me",
+		WithGitRunner(&ExecGitRunner{Dir: repo.dir}), WithLinkerVars())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != "9.9.9" || info.GITInfo.CommitHashShort != "0123456" {
+		t.Errorf("Version, CommitHashShort = %q, %q; want linker values", info.Version, info.GITInfo.CommitHashShort)
+	}
+	// Ветка сборки сохраняется, но не используется как ссылка для git
+	if info.GITInfo.BranchName != "linked-branch" {
+		t.Errorf("BranchName = %q, want linked-branch", info.GITInfo.BranchName)
+	}
+	if want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !info.BuildDate.Equal(want) {
+		t.Errorf("BuildDate = %v, want %v", info.BuildDate, want)
+	}
+	if len(info.Warnings) != 0 {
+		t.Errorf("Warnings = %q", info.Warnings)
+	}
+}
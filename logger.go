@@ -0,0 +1,30 @@
+package mkversions
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetDefaultLogger задает логгер пакета для Info, созданных без WithLogger.
+// nil возвращает поведение по умолчанию - slog.Default().
+func SetDefaultLogger(logger *slog.Logger) {
+	defaultLogger.Store(logger)
+}
+
+// DefaultLogger возвращает логгер пакета
+func DefaultLogger() *slog.Logger {
+	if logger := defaultLogger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// log возвращает логгер Info или логгер пакета
+func (info *Info) log() *slog.Logger {
+	if info.logger != nil {
+		return info.logger
+	}
+	return DefaultLogger()
+}
@@ -0,0 +1,124 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Mailmap сопоставляет имена и адреса из коммитов каноническим по правилам
+// файла .mailmap (см. gitmailmap(5))
+type Mailmap struct {
+	entries map[string]*mailmapEntry
+}
+
+type mailmapEntry struct {
+	// Замена для любого имени с этим адресом
+	name, email string
+	// Замены для конкретных имен с этим адресом, ключ - имя в нижнем регистре
+	byName map[string][2]string
+}
+
+// ParseMailmap разбирает содержимое файла .mailmap
+func ParseMailmap(data []byte) *Mailmap {
+	m := &Mailmap{entries: map[string]*mailmapEntry{}}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name1, email1, rest, ok := parseMailmapIdentity(line)
+		if !ok {
+			continue
+		}
+		properName, properEmail, commitName, commitEmail := name1, "", "", email1
+		if name2, email2, _, ok := parseMailmapIdentity(rest); ok {
+			properEmail, commitName, commitEmail = email1, name2, email2
+		}
+		m.add(properName, properEmail, commitName, commitEmail)
+	}
+	return m
+}
+
+// parseMailmapIdentity выделяет "Имя <адрес>" из начала s
+func parseMailmapIdentity(s string) (name, email, rest string, ok bool) {
+	open := strings.IndexByte(s, '<')
+	if open == -1 {
+		return "", "", "", false
+	}
+	end := strings.IndexByte(s[open:], '>')
+	if end == -1 {
+		return "", "", "", false
+	}
+	end += open
+	return strings.TrimSpace(s[:open]), strings.TrimSpace(s[open+1 : end]), s[end+1:], true
+}
+
+func (m *Mailmap) add(properName, properEmail, commitName, commitEmail string) {
+	key := strings.ToLower(commitEmail)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &mailmapEntry{byName: map[string][2]string{}}
+		m.entries[key] = e
+	}
+	if commitName == "" {
+		if properName != "" {
+			e.name = properName
+		}
+		if properEmail != "" {
+			e.email = properEmail
+		}
+		return
+	}
+	e.byName[strings.ToLower(commitName)] = [2]string{properName, properEmail}
+}
+
+// ReadMailmap читает файл .mailmap. Отсутствующий файл дает пустой Mailmap.
+func ReadMailmap(path string) (*Mailmap, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ParseMailmap(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mailmap %s: %v", path, err)
+	}
+	return ParseMailmap(data), nil
+}
+
+// Mailmap читает .mailmap из корня рабочей копии
+func (r *Repo) Mailmap(ctx context.Context) (*Mailmap, error) {
+	topLevel, err := r.run(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, wrapGitError("failed to get Git top-level directory", err)
+	}
+	return ReadMailmap(filepath.Join(strings.TrimSpace(topLevel), ".mailmap"))
+}
+
+// Lookup возвращает каноническое имя и адрес. Сравнение имен и адресов
+// не зависит от регистра; nil Mailmap возвращает значения без изменений.
+func (m *Mailmap) Lookup(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+	e, ok := m.entries[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+
+	properName, properEmail := e.name, e.email
+	if proper, ok := e.byName[strings.ToLower(name)]; ok {
+		properName, properEmail = proper[0], proper[1]
+	}
+	if properName != "" {
+		name = properName
+	}
+	if properEmail != "" {
+		email = properEmail
+	}
+	return name, email
+}
@@ -0,0 +1,98 @@
+package mkversions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testMailmap содержит все четыре формы строк gitmailmap(5)
+const testMailmap = `# комментарий
+Proper Name <commit@example.com>
+<proper@example.com> <old@example.com>
+Both Proper <both@example.com> <both-old@example.com>
+Named Proper <named@example.com> Commit Name <shared@example.com>
+Other Proper <other@example.com> Other Name <shared@example.com>
+
+broken line without email
+`
+
+func TestMailmapLookup(t *testing.T) {
+	m := ParseMailmap([]byte(testMailmap))
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		// Имя <адрес>: заменяется только имя
+		{"nick", "commit@example.com", "Proper Name", "commit@example.com"},
+		// <адрес> <адрес>: заменяется только адрес
+		{"Old Me", "old@example.com", "Old Me", "proper@example.com"},
+		// Имя <адрес> <адрес>: заменяются оба
+		{"whoever", "both-old@example.com", "Both Proper", "both@example.com"},
+		// Имя <адрес> Имя <адрес>: замена зависит от имени в коммите
+		{"Commit Name", "shared@example.com", "Named Proper", "named@example.com"},
+		{"Other Name", "shared@example.com", "Other Proper", "other@example.com"},
+		{"Third Name", "shared@example.com", "Third Name", "shared@example.com"},
+		// Регистр адресов и имен не учитывается
+		{"nick", "Commit@Example.COM", "Proper Name", "Commit@Example.COM"},
+		{"commit name", "SHARED@example.com", "Named Proper", "named@example.com"},
+		{"Someone", "someone@example.com", "Someone", "someone@example.com"},
+	}
+	for _, tt := range tests {
+		name, email := m.Lookup(tt.name, tt.email)
+		if name != tt.wantName || email != tt.wantEmail {
+			t.Errorf("Lookup(%q, %q) = %q, %q; want %q, %q", tt.name, tt.email, name, email, tt.wantName, tt.wantEmail)
+		}
+	}
+
+	var nilMap *Mailmap
+	if name, email := nilMap.Lookup("a", "a@example.com"); name != "a" || email != "a@example.com" {
+		t.Errorf("nil Lookup = %q, %q", name, email)
+	}
+}
+
+// TestMailmapGitParity сравнивает Lookup с git check-mailmap
+func TestMailmapGitParity(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.write(".mailmap", testMailmap)
+	repo.commit("a.txt", "a\n", "feat: a")
+
+	m, err := NewRepo(&ExecGitRunner{Dir: repo.dir}).Mailmap(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range [][2]string{
+		{"nick", "commit@example.com"},
+		{"Old Me", "old@example.com"},
+		{"whoever", "both-old@example.com"},
+		{"Commit Name", "shared@example.com"},
+		{"commit name", "SHARED@example.com"},
+		{"Third Name", "shared@example.com"},
+		{"nick", "Commit@Example.COM"},
+	} {
+		name, email := m.Lookup(id[0], id[1])
+		got := name + " <" + email + ">"
+		if want := repo.git("check-mailmap", id[0]+" <"+id[1]+">"); got != want {
+			t.Errorf("Lookup(%q, %q) = %q, git check-mailmap = %q", id[0], id[1], got, want)
+		}
+	}
+}
+
+func TestReadMailmapMissing(t *testing.T) {
+	m, err := ReadMailmap(filepath.Join(t.TempDir(), ".mailmap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, email := m.Lookup("a", "a@example.com"); name != "a" || email != "a@example.com" {
+		t.Errorf("Lookup = %q, %q", name, email)
+	}
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".mailmap"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMailmap(filepath.Join(dir, ".mailmap")); err == nil {
+		t.Error("ReadMailmap of a directory succeeded")
+	}
+}
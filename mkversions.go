@@ -1,8 +1,7 @@
 package mkversions
 
 import (
-	"bytes"
-	"os/exec"
+	"log/slog"
 	"time"
 )
 
@@ -57,6 +56,20 @@ func WithProductVersion(productVersion string) Option {
 	}
 }
 
+// WithIcon задает .ico файл значка для WriteSyso
+func WithIcon(path string) Option {
+	return func(info *Info) {
+		info.IconFile = path
+	}
+}
+
+// WithManifest задает файл манифеста приложения для WriteSyso
+func WithManifest(path string) Option {
+	return func(info *Info) {
+		info.ManifestFile = path
+	}
+}
+
 func WithGoVersion(goVersion string) Option {
 	return func(info *Info) {
 		info.GoVersion = goVersion
@@ -92,9 +105,11 @@ func WithCommitHash(hash string) Option {
 	}
 }
 
+// WithBranchName задает ветку, по которой определяются хэш, дата, версия и журнал изменений
 func WithBranchName(branch string) Option {
 	return func(info *Info) {
 		info.GITInfo.BranchName = branch
+		info.branchRef = branch
 	}
 }
 
@@ -110,19 +125,90 @@ func WithLogSince(since time.Time) Option {
 	}
 }
 
+// WithChangelogRange задает журнал изменений как коммиты from..to.
+// Пустой from означает все коммиты до to, пустой to - HEAD.
+func WithChangelogRange(from, to string) Option {
+	return func(info *Info) {
+		info.GITInfo.ChangelogFrom = from
+		info.GITInfo.ChangelogTo = to
+		info.changelogRange = true
+	}
+}
+
+// WithChangelogStats заполняет Additions и Deletions коммитов журнала
+// изменений, например для Changelog.Contributors
+func WithChangelogStats() Option {
+	return func(info *Info) {
+		info.changelogStats = true
+	}
+}
+
+// WithReferencePatterns задает шаблоны ссылок на задачи для журнала
+// изменений вместо DefaultReferencePatterns
+func WithReferencePatterns(patterns ...ReferencePattern) Option {
+	return func(info *Info) {
+		info.referencePatterns = patterns
+	}
+}
+
 func WithBuildDate(newBuildDate time.Time) Option {
 	return func(info *Info) {
 		info.BuildDate = newBuildDate
 	}
 }
 
-func runGitCommand(args ...string) (string, string, error) {
-	cmd := exec.Command("git", args...)
+// WithVersionFromGit задает версию по ближайшему SemVer тегу (git describe)
+func WithVersionFromGit() Option {
+	return func(info *Info) {
+		info.versionFromGit = true
+	}
+}
+
+// WithTagPrefix задает префикс тегов версий, например "v" или "svc/api/v"
+func WithTagPrefix(prefix string) Option {
+	return func(info *Info) {
+		info.tagPrefix = prefix
+	}
+}
+
+// WithModule ограничивает данные git каталогом модуля dir относительно корня
+// репозитория: хэш, дата, состояние рабочей копии и журнал берутся по коммитам,
+// затрагивающим dir, а теги версий ищутся с префиксом "<dir>/<префикс>",
+// например "services/api/v1.2.0". Каталоги вложенных модулей не учитываются,
+// поэтому корневой модуль "." не включает остальные модули репозитория.
+func WithModule(dir string) Option {
+	return func(info *Info) {
+		info.Module = cleanModuleDir(dir)
+		info.moduleSet = true
+	}
+}
+
+// WithDirtyExclude исключает пути из проверки незакоммиченных изменений
+func WithDirtyExclude(paths ...string) Option {
+	return func(info *Info) {
+		info.dirtyExclude = append(info.dirtyExclude, paths...)
+	}
+}
+
+// WithGitTimeout ограничивает время выполнения каждой команды git.
+// d <= 0 отключает ограничение; по умолчанию используется DefaultGitTimeout.
+func WithGitTimeout(d time.Duration) Option {
+	return func(info *Info) {
+		info.gitTimeout = &d
+	}
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// WithGitRunner задает способ запуска git, например ExecGitRunner с другим
+// рабочим каталогом или подставной GitRunner в тестах
+func WithGitRunner(runner GitRunner) Option {
+	return func(info *Info) {
+		info.gitRunner = runner
+	}
+}
 
-	err := cmd.Run()
-	return stdout.String(), stderr.String(), err
+// WithLogger задает логгер для диагностических сообщений
+func WithLogger(logger *slog.Logger) Option {
+	return func(info *Info) {
+		info.logger = logger
+	}
 }
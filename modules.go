@@ -0,0 +1,150 @@
+package mkversions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cleanModuleDir приводит каталог модуля к виду "services/api"; корень дает ""
+func cleanModuleDir(dir string) string {
+	dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// TagPrefix возвращает префикс тегов версий с учетом модуля, например "services/api/v"
+func (info *Info) TagPrefix() string {
+	if info.Module == "" {
+		return info.tagPrefix
+	}
+	return info.Module + "/" + info.tagPrefix
+}
+
+// modulePaths возвращает pathspec модуля (см. Repo.ModulePathspec) или nil
+// для всего репозитория без WithModule
+func (info *Info) modulePaths(ctx context.Context, repo *Repo) ([]string, error) {
+	if !info.moduleSet {
+		return nil, nil
+	}
+	return repo.ModulePathspec(ctx, info.Module)
+}
+
+// ModulePathspec возвращает pathspec каталога модуля dir относительно корня
+// репозитория без каталогов вложенных модулей. Для корневого модуля "."
+// без вложенных модулей возвращается nil - весь репозиторий.
+func (r *Repo) ModulePathspec(ctx context.Context, dir string) ([]string, error) {
+	dir = cleanModuleDir(dir)
+	modules, err := r.Modules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{":/" + dir}
+	for _, m := range modules {
+		if m != "." && m != dir && (dir == "" || strings.HasPrefix(m, dir+"/")) {
+			paths = append(paths, ":(top,exclude)"+m)
+		}
+	}
+	if dir == "" && len(paths) == 1 {
+		return nil, nil
+	}
+	return paths, nil
+}
+
+// prepareCommit заменяет хэш, дату и теги данными последнего коммита ref
+// (по умолчанию HEAD), затрагивающего paths. Теги берутся только для ref.
+func (info *Info) prepareCommit(ctx context.Context, repo *Repo, cache *gitStateCache, ref string, paths []string, commitFromGit, dateFromGit bool) error {
+	if !commitFromGit && !dateFromGit && ref == "" {
+		return nil
+	}
+	out, err := cachedValue(cache, stateCacheKey("commit", ref, "", paths), func() (string, error) {
+		return repo.lastCommit(ctx, "failed to get Git commit", "%H%x00%cd%x00%D", ref, paths)
+	})
+	if err != nil {
+		return err
+	}
+	fields := strings.SplitN(out, "\x00", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("failed to parse Git commit %q", out)
+	}
+	if commitFromGit {
+		info.GITInfo.CommitHash = fields[0]
+		info.GITInfo.CommitHashShort = fields[0][:min(7, len(fields[0]))]
+	}
+	if dateFromGit {
+		if info.GITInfo.CommitDate, err = parseRawGitDate(fields[1]); err != nil {
+			return err
+		}
+	}
+	if ref != "" {
+		info.GITInfo.Tags = decorationTags(fields[2])
+	}
+	return nil
+}
+
+// NewModuleInfos строит независимые Info для каталогов модулей dirs относительно
+// корня репозитория (см. WithModule). Версия модуля берется из его тегов.
+// Info возвращаются для всех модулей, ошибки объединяются через errors.Join.
+func NewModuleInfos(ctx context.Context, dirs []string, releaseType, developer string, opts ...Option) ([]*Info, error) {
+	var infos []*Info
+	var errs []error
+	for _, dir := range dirs {
+		moduleOpts := append(opts[:len(opts):len(opts)], WithModule(dir))
+		info, err := NewInfoContext(ctx, "", releaseType, developer, moduleOpts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", dir, err))
+		}
+		infos = append(infos, info)
+	}
+	return infos, errors.Join(errs...)
+}
+
+// GetGitModules возвращает каталоги отслеживаемых файлов go.mod относительно
+// корня репозитория; корневой модуль обозначается ".". Каталоги vendor и
+// testdata пропускаются.
+func GetGitModules() ([]string, error) {
+	return GetGitModulesContext(context.Background())
+}
+
+// GetGitModulesContext работает как GetGitModules с контекстом
+func GetGitModulesContext(ctx context.Context) ([]string, error) {
+	return defaultRepo.Modules(ctx)
+}
+
+// Modules работает как GetGitModules для этого репозитория
+func (r *Repo) Modules(ctx context.Context) ([]string, error) {
+	stdout, err := r.run(ctx, "ls-files", "-z", "--full-name", "--", ":(top,glob)**/go.mod")
+	if err != nil {
+		return nil, wrapGitError("failed to list Go modules", err)
+	}
+
+	var dirs []string
+	for _, file := range strings.Split(stdout, "\x00") {
+		if file == "" {
+			continue
+		}
+		dir := path.Dir(file)
+		if skipModuleDir(dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func skipModuleDir(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if part == "vendor" || part == "testdata" {
+			return true
+		}
+	}
+	return false
+}
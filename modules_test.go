@@ -0,0 +1,91 @@
+package mkversions
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestModulePathspec(t *testing.T) {
+	const lsFiles = "go.mod\x00svc/a/go.mod\x00svc/a/sub/go.mod\x00svc/ab/go.mod\x00svc/b/go.mod\x00" +
+		"vendor/x/go.mod\x00svc/a/testdata/m/go.mod\x00"
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		if want := []string{"ls-files", "-z", "--full-name", "--", ":(top,glob)**/go.mod"}; !slices.Equal(args, want) {
+			t.Errorf("git %q, want %q", args, want)
+		}
+		return lsFiles, nil
+	}))
+
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		// Вложенный модуль исключается, соседний svc/ab с общим префиксом - нет
+		{"svc/a", []string{":/svc/a", ":(top,exclude)svc/a/sub"}},
+		{"./svc/a/", []string{":/svc/a", ":(top,exclude)svc/a/sub"}},
+		{"svc/b", []string{":/svc/b"}},
+		{"svc/a/sub", []string{":/svc/a/sub"}},
+		// Корневой модуль исключает все остальные, кроме vendor и testdata
+		{"", []string{":/", ":(top,exclude)svc/a", ":(top,exclude)svc/a/sub", ":(top,exclude)svc/ab", ":(top,exclude)svc/b"}},
+		{".", []string{":/", ":(top,exclude)svc/a", ":(top,exclude)svc/a/sub", ":(top,exclude)svc/ab", ":(top,exclude)svc/b"}},
+	}
+	for _, tt := range tests {
+		got, err := repo.ModulePathspec(context.Background(), tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ModulePathspec(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestModulePathspecSingleModule(t *testing.T) {
+	repo := NewRepo(gitRunnerFunc(func(ctx context.Context, args ...string) (string, error) {
+		return "go.mod\x00vendor/x/go.mod\x00", nil
+	}))
+	got, err := repo.ModulePathspec(context.Background(), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("ModulePathspec(.) = %q, want nil for the whole repository", got)
+	}
+}
+
+// TestModulePathspecGit проверяет, что git применяет исключения из pathspec
+func TestModulePathspecGit(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.commit("go.mod", "module example.com/root\n", "feat: root")
+	repo.commit("svc/a/go.mod", "module example.com/root/svc/a\n", "feat: svc/a")
+	repo.commit("svc/a/sub/go.mod", "module example.com/root/svc/a/sub\n", "feat: svc/a/sub")
+	repo.commit("svc/a/a.go", "package a\n", "fix: svc/a code")
+	repo.commit("main.go", "package main\n", "fix: root code")
+
+	r := NewRepo(&ExecGitRunner{Dir: repo.dir})
+	ctx := context.Background()
+	for _, tt := range []struct {
+		dir  string
+		want []string
+	}{
+		{".", []string{"fix: root code", "feat: root"}},
+		{"svc/a", []string{"fix: svc/a code", "feat: svc/a"}},
+		{"svc/a/sub", []string{"feat: svc/a/sub"}},
+	} {
+		paths, err := r.ModulePathspec(ctx, tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cl, err := r.ChangelogRange(ctx, "", "HEAD", paths...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range cl.Commits {
+			got = append(got, c.Message)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("module %s changelog = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
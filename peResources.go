@@ -0,0 +1,373 @@
+package mkversions
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// Типы ресурсов Windows
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	rtVersion   = 16
+	rtManifest  = 24
+)
+
+const (
+	resourceDirectorySize = 16
+	resourceEntrySize     = 8
+	resourceDataEntrySize = 16
+)
+
+// resourceID - идентификатор ресурса: строковое имя или числовой ID
+type resourceID struct {
+	Name string
+	ID   uint16
+}
+
+func (id resourceID) less(other resourceID) bool {
+	// Именованные записи идут перед числовыми
+	switch {
+	case id.Name != "" && other.Name == "":
+		return true
+	case id.Name == "" && other.Name != "":
+		return false
+	case id.Name != "":
+		return id.Name < other.Name
+	}
+	return id.ID < other.ID
+}
+
+// resourceEntry - один ресурс (тип/имя/язык) и его данные
+type resourceEntry struct {
+	Type     resourceID
+	Name     resourceID
+	Lang     uint16
+	CodePage uint32
+	Data     []byte
+}
+
+// resourceSet - плоский набор ресурсов, из которого строится дерево каталогов .rsrc
+type resourceSet struct {
+	entries []*resourceEntry
+}
+
+// remove удаляет все ресурсы заданного типа
+func (rs *resourceSet) remove(typ resourceID) {
+	kept := rs.entries[:0]
+	for _, e := range rs.entries {
+		if e.Type != typ {
+			kept = append(kept, e)
+		}
+	}
+	rs.entries = kept
+}
+
+func (rs *resourceSet) add(e *resourceEntry) {
+	rs.entries = append(rs.entries, e)
+}
+
+// find возвращает первый ресурс заданного типа
+func (rs *resourceSet) find(typ resourceID) *resourceEntry {
+	for _, e := range rs.entries {
+		if e.Type == typ {
+			return e
+		}
+	}
+	return nil
+}
+
+// parseResourceSet разбирает дерево ресурсов из данных секции, начинающейся по адресу base.
+// readRVA используется для данных, лежащих за пределами секции.
+func parseResourceSet(data []byte, base uint32, readRVA func(rva, size uint32) ([]byte, error)) (*resourceSet, error) {
+	rs := &resourceSet{}
+	p := &resourceParser{data: data, base: base, readRVA: readRVA}
+
+	types, err := p.directory(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, typ := range types {
+		if !typ.isDir {
+			return nil, fmt.Errorf("malformed resource directory: type entry is not a directory")
+		}
+		names, err := p.directory(typ.offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !name.isDir {
+				return nil, fmt.Errorf("malformed resource directory: name entry is not a directory")
+			}
+			langs, err := p.directory(name.offset)
+			if err != nil {
+				return nil, err
+			}
+			for _, lang := range langs {
+				if lang.isDir {
+					return nil, fmt.Errorf("malformed resource directory: unexpected fourth level")
+				}
+				entry, err := p.dataEntry(lang.offset)
+				if err != nil {
+					return nil, err
+				}
+				entry.Type = typ.id
+				entry.Name = name.id
+				entry.Lang = lang.id.ID
+				rs.add(entry)
+			}
+		}
+	}
+	return rs, nil
+}
+
+type resourceDirEntry struct {
+	id     resourceID
+	isDir  bool
+	offset uint32
+}
+
+type resourceParser struct {
+	data    []byte
+	base    uint32
+	readRVA func(rva, size uint32) ([]byte, error)
+}
+
+func (p *resourceParser) directory(offset uint32) ([]resourceDirEntry, error) {
+	if uint64(offset)+resourceDirectorySize > uint64(len(p.data)) {
+		return nil, fmt.Errorf("malformed resource directory at offset %#x", offset)
+	}
+
+	named := binary.LittleEndian.Uint16(p.data[offset+12:])
+	ids := binary.LittleEndian.Uint16(p.data[offset+14:])
+	count := uint32(named) + uint32(ids)
+	start := offset + resourceDirectorySize
+	if uint64(start)+uint64(count)*resourceEntrySize > uint64(len(p.data)) {
+		return nil, fmt.Errorf("malformed resource directory at offset %#x: entries out of range", offset)
+	}
+
+	entries := make([]resourceDirEntry, 0, count)
+	for i := uint32(0); i < count; i++ {
+		pos := start + i*resourceEntrySize
+		nameField := binary.LittleEndian.Uint32(p.data[pos:])
+		dataField := binary.LittleEndian.Uint32(p.data[pos+4:])
+
+		var entry resourceDirEntry
+		if nameField&0x80000000 != 0 {
+			name, err := p.name(nameField &^ 0x80000000)
+			if err != nil {
+				return nil, err
+			}
+			entry.id.Name = name
+		} else {
+			entry.id.ID = uint16(nameField)
+		}
+		entry.isDir = dataField&0x80000000 != 0
+		entry.offset = dataField &^ 0x80000000
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (p *resourceParser) name(offset uint32) (string, error) {
+	if uint64(offset)+2 > uint64(len(p.data)) {
+		return "", fmt.Errorf("malformed resource name at offset %#x", offset)
+	}
+	length := uint32(binary.LittleEndian.Uint16(p.data[offset:]))
+	if uint64(offset)+2+uint64(length)*2 > uint64(len(p.data)) {
+		return "", fmt.Errorf("malformed resource name at offset %#x", offset)
+	}
+	return decodeUTF16(p.data[offset+2 : offset+2+length*2]), nil
+}
+
+func (p *resourceParser) dataEntry(offset uint32) (*resourceEntry, error) {
+	if uint64(offset)+resourceDataEntrySize > uint64(len(p.data)) {
+		return nil, fmt.Errorf("malformed resource data entry at offset %#x", offset)
+	}
+	rva := binary.LittleEndian.Uint32(p.data[offset:])
+	size := binary.LittleEndian.Uint32(p.data[offset+4:])
+	codePage := binary.LittleEndian.Uint32(p.data[offset+8:])
+
+	var data []byte
+	if rva >= p.base && uint64(rva-p.base)+uint64(size) <= uint64(len(p.data)) {
+		data = append([]byte(nil), p.data[rva-p.base:rva-p.base+size]...)
+	} else {
+		if p.readRVA == nil {
+			return nil, fmt.Errorf("resource data at RVA %#x is outside of the resource section", rva)
+		}
+		var err error
+		data, err = p.readRVA(rva, size)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &resourceEntry{CodePage: codePage, Data: data}, nil
+}
+
+// resourceNode - узел дерева каталогов при сериализации
+type resourceNode struct {
+	id       resourceID
+	children []*resourceNode
+	entry    *resourceEntry
+	offset   uint32
+}
+
+// serialize строит секцию ресурсов, которая будет размещена по адресу base.
+// Возвращает данные секции и смещения полей с RVA, для которых нужны релокации
+// при сборке объектного файла.
+func (rs *resourceSet) serialize(base uint32) ([]byte, []uint32) {
+	root := rs.tree()
+
+	// Каталоги по уровням: корень, типы, имена
+	levels := [][]*resourceNode{{root}}
+	for level := 0; level < 2; level++ {
+		var next []*resourceNode
+		for _, node := range levels[level] {
+			next = append(next, node.children...)
+		}
+		levels = append(levels, next)
+	}
+
+	var offset uint32
+	for _, level := range levels {
+		for _, node := range level {
+			node.offset = offset
+			offset += resourceDirectorySize + uint32(len(node.children))*resourceEntrySize
+		}
+	}
+
+	var leaves []*resourceNode
+	for _, node := range levels[2] {
+		leaves = append(leaves, node.children...)
+	}
+	for _, leaf := range leaves {
+		leaf.offset = offset
+		offset += resourceDataEntrySize
+	}
+
+	// Строковые имена
+	nameOffsets := make(map[string]uint32)
+	var names []string
+	for _, level := range levels[1:] {
+		for _, node := range level {
+			if node.id.Name == "" {
+				continue
+			}
+			if _, ok := nameOffsets[node.id.Name]; ok {
+				continue
+			}
+			nameOffsets[node.id.Name] = offset
+			names = append(names, node.id.Name)
+			offset += 2 + uint32(len(utf16.Encode([]rune(node.id.Name))))*2
+		}
+	}
+
+	dataOffsets := make([]uint32, len(leaves))
+	for i, leaf := range leaves {
+		offset = alignUp(offset, 8)
+		dataOffsets[i] = offset
+		offset += uint32(len(leaf.entry.Data))
+	}
+	offset = alignUp(offset, 8)
+
+	buf := make([]byte, offset)
+	var relocs []uint32
+
+	for _, level := range levels {
+		for _, node := range level {
+			var named, ids uint16
+			for _, child := range node.children {
+				if child.id.Name != "" {
+					named++
+				} else {
+					ids++
+				}
+			}
+			binary.LittleEndian.PutUint16(buf[node.offset+12:], named)
+			binary.LittleEndian.PutUint16(buf[node.offset+14:], ids)
+
+			for i, child := range node.children {
+				pos := node.offset + resourceDirectorySize + uint32(i)*resourceEntrySize
+				if child.id.Name != "" {
+					binary.LittleEndian.PutUint32(buf[pos:], nameOffsets[child.id.Name]|0x80000000)
+				} else {
+					binary.LittleEndian.PutUint32(buf[pos:], uint32(child.id.ID))
+				}
+				if child.entry != nil {
+					binary.LittleEndian.PutUint32(buf[pos+4:], child.offset)
+				} else {
+					binary.LittleEndian.PutUint32(buf[pos+4:], child.offset|0x80000000)
+				}
+			}
+		}
+	}
+
+	for i, leaf := range leaves {
+		binary.LittleEndian.PutUint32(buf[leaf.offset:], base+dataOffsets[i])
+		binary.LittleEndian.PutUint32(buf[leaf.offset+4:], uint32(len(leaf.entry.Data)))
+		binary.LittleEndian.PutUint32(buf[leaf.offset+8:], leaf.entry.CodePage)
+		relocs = append(relocs, leaf.offset)
+		copy(buf[dataOffsets[i]:], leaf.entry.Data)
+	}
+
+	for _, name := range names {
+		pos := nameOffsets[name]
+		encoded := utf16.Encode([]rune(name))
+		binary.LittleEndian.PutUint16(buf[pos:], uint16(len(encoded)))
+		for i, c := range encoded {
+			binary.LittleEndian.PutUint16(buf[pos+2+uint32(i)*2:], c)
+		}
+	}
+
+	return buf, relocs
+}
+
+// tree группирует ресурсы в отсортированное трехуровневое дерево
+func (rs *resourceSet) tree() *resourceNode {
+	root := &resourceNode{}
+	child := func(parent *resourceNode, id resourceID) *resourceNode {
+		for _, c := range parent.children {
+			if c.id == id {
+				return c
+			}
+		}
+		c := &resourceNode{id: id}
+		parent.children = append(parent.children, c)
+		return c
+	}
+
+	for _, e := range rs.entries {
+		name := child(child(root, e.Type), e.Name)
+		lang := child(name, resourceID{ID: e.Lang})
+		lang.entry = e
+	}
+
+	var sortNode func(n *resourceNode)
+	sortNode = func(n *resourceNode) {
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].id.less(n.children[j].id)
+		})
+		for _, c := range n.children {
+			sortNode(c)
+		}
+	}
+	sortNode(root)
+	return root
+}
+
+func alignUp(v, align uint32) uint32 {
+	if align == 0 {
+		return v
+	}
+	return (v + align - 1) / align * align
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,346 @@
+package mkversions
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+const (
+	peDirectoryResource = 2
+	peDirectorySecurity = 4
+
+	peSectionHeaderSize = 40
+
+	// IMAGE_SCN_CNT_INITIALIZED_DATA | IMAGE_SCN_MEM_READ
+	peResourceCharacteristics = 0x40000040
+)
+
+// WriteExeMetadata записывает ресурс VERSIONINFO, построенный из Version и
+// AppMetadata, в PE файл exePath. Работает на любой ОС без внешних утилит.
+// Цифровая подпись файла, если она была, удаляется: после изменения она недействительна.
+func (i *Info) WriteExeMetadata(exePath string) error {
+	return WriteVersionResource(exePath, i.VersionResource())
+}
+
+// WriteVersionResource заменяет ресурс RT_VERSION в PE файле exePath
+func WriteVersionResource(exePath string, vr *VersionResource) error {
+	return updatePEResources(exePath, func(rs *resourceSet) error {
+		rs.remove(resourceID{ID: rtVersion})
+		rs.add(&resourceEntry{
+			Type: resourceID{ID: rtVersion},
+			Name: resourceID{ID: 1},
+			Lang: vr.Language(),
+			Data: vr.Bytes(),
+		})
+		return nil
+	})
+}
+
+// ReadExeMetadata читает ресурс VERSIONINFO из PE файла exePath
+func ReadExeMetadata(exePath string) (*VersionResource, error) {
+	data, err := os.ReadFile(exePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", exePath, err)
+	}
+	img, err := parsePEImage(data)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := img.resources()
+	if err != nil {
+		return nil, err
+	}
+	entry := rs.find(resourceID{ID: rtVersion})
+	if entry == nil {
+		return nil, fmt.Errorf("no VERSIONINFO resource in %s", exePath)
+	}
+	return parseVersionResource(entry.Data)
+}
+
+// peImage - разобранные заголовки PE файла с позициями полей для записи
+type peImage struct {
+	data             []byte
+	file             *pe.File
+	optionalOffset   int
+	sectionTableOff  int
+	is64             bool
+	sectionAlignment uint32
+	fileAlignment    uint32
+	sizeOfHeaders    uint32
+}
+
+func parsePEImage(data []byte) (*peImage, error) {
+	f, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PE file: %v", err)
+	}
+
+	img := &peImage{data: data, file: f}
+	peOffset := int(binary.LittleEndian.Uint32(data[0x3c:]))
+	img.optionalOffset = peOffset + 4 + 20
+	img.sectionTableOff = img.optionalOffset + int(f.FileHeader.SizeOfOptionalHeader)
+
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		img.sectionAlignment, img.fileAlignment, img.sizeOfHeaders = oh.SectionAlignment, oh.FileAlignment, oh.SizeOfHeaders
+	case *pe.OptionalHeader64:
+		img.is64 = true
+		img.sectionAlignment, img.fileAlignment, img.sizeOfHeaders = oh.SectionAlignment, oh.FileAlignment, oh.SizeOfHeaders
+	default:
+		return nil, fmt.Errorf("PE file has no optional header")
+	}
+	return img, nil
+}
+
+// dataDirectoryOffset возвращает смещение записи каталога данных index в файле
+func (img *peImage) dataDirectoryOffset(index int) (int, bool) {
+	countOffset, dirOffset := 92, 96
+	if img.is64 {
+		countOffset, dirOffset = 108, 112
+	}
+	count := int(binary.LittleEndian.Uint32(img.data[img.optionalOffset+countOffset:]))
+	if index >= count {
+		return 0, false
+	}
+	return img.optionalOffset + dirOffset + index*8, true
+}
+
+func (img *peImage) dataDirectory(index int) (rva, size uint32) {
+	off, ok := img.dataDirectoryOffset(index)
+	if !ok {
+		return 0, 0
+	}
+	return binary.LittleEndian.Uint32(img.data[off:]), binary.LittleEndian.Uint32(img.data[off+4:])
+}
+
+// sectionFor возвращает секцию, содержащую rva
+func (img *peImage) sectionFor(rva uint32) (int, *pe.Section) {
+	for idx, s := range img.file.Sections {
+		size := s.VirtualSize
+		if size < s.Size {
+			size = s.Size
+		}
+		if rva >= s.VirtualAddress && rva < s.VirtualAddress+size {
+			return idx, s
+		}
+	}
+	return -1, nil
+}
+
+func (img *peImage) readRVA(rva, size uint32) ([]byte, error) {
+	_, s := img.sectionFor(rva)
+	if s == nil {
+		return nil, fmt.Errorf("RVA %#x is not mapped by any section", rva)
+	}
+	off := uint64(s.Offset) + uint64(rva-s.VirtualAddress)
+	if uint64(rva-s.VirtualAddress)+uint64(size) > uint64(s.Size) || off+uint64(size) > uint64(len(img.data)) {
+		return nil, fmt.Errorf("RVA %#x+%#x is outside of section data", rva, size)
+	}
+	return append([]byte(nil), img.data[off:off+uint64(size)]...), nil
+}
+
+// resources разбирает существующее дерево ресурсов; пустой набор, если его нет
+func (img *peImage) resources() (*resourceSet, error) {
+	rva, size := img.dataDirectory(peDirectoryResource)
+	if rva == 0 || size == 0 {
+		return &resourceSet{}, nil
+	}
+	_, s := img.sectionFor(rva)
+	if s == nil {
+		return nil, fmt.Errorf("resource directory RVA %#x is not mapped by any section", rva)
+	}
+	start := uint64(s.Offset) + uint64(rva-s.VirtualAddress)
+	end := uint64(s.Offset) + uint64(s.Size)
+	if end > uint64(len(img.data)) || start >= end {
+		return nil, fmt.Errorf("resource section is outside of the file")
+	}
+	return parseResourceSet(img.data[start:end], rva, img.readRVA)
+}
+
+// updatePEResources читает PE файл, изменяет набор ресурсов через mutate
+// и записывает файл обратно. Ресурсы переписываются на месте, если помещаются
+// в прежнюю секцию, иначе добавляется новая секция .rsrc в конец образа.
+func updatePEResources(path string, mutate func(rs *resourceSet) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	img, err := parsePEImage(data)
+	if err != nil {
+		return err
+	}
+
+	rs, err := img.resources()
+	if err != nil {
+		return err
+	}
+	if err := mutate(rs); err != nil {
+		return err
+	}
+
+	out, err := img.withResources(rs)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// withResources возвращает новый образ файла с ресурсами rs
+func (img *peImage) withResources(rs *resourceSet) ([]byte, error) {
+	out := append([]byte(nil), img.data...)
+
+	// Подпись Authenticode перестает быть действительной - удаляем ее
+	if certOffset, certSize := img.dataDirectory(peDirectorySecurity); certOffset != 0 && certSize != 0 {
+		if uint64(certOffset)+uint64(certSize) >= uint64(len(out)) && int(certOffset) <= len(out) {
+			out = out[:certOffset]
+		}
+		off, _ := img.dataDirectoryOffset(peDirectorySecurity)
+		binary.LittleEndian.PutUint32(out[off:], 0)
+		binary.LittleEndian.PutUint32(out[off+4:], 0)
+	}
+
+	dirOffset, ok := img.dataDirectoryOffset(peDirectoryResource)
+	if !ok {
+		return nil, fmt.Errorf("PE file has no resource data directory entry")
+	}
+
+	// Попытка переписать ресурсы на месте
+	rva, _ := img.dataDirectory(peDirectoryResource)
+	if rva != 0 {
+		if idx, s := img.sectionFor(rva); s != nil && s.VirtualAddress == rva {
+			payload, _ := rs.serialize(rva)
+			capacity := s.Size
+			if idx+1 < len(img.file.Sections) {
+				if limit := img.file.Sections[idx+1].VirtualAddress - s.VirtualAddress; limit < capacity {
+					capacity = limit
+				}
+			}
+			if uint32(len(payload)) <= capacity {
+				region := out[s.Offset : s.Offset+s.Size]
+				for i := range region {
+					region[i] = 0
+				}
+				copy(region, payload)
+
+				hdr := img.sectionTableOff + idx*peSectionHeaderSize
+				if uint32(len(payload)) > s.VirtualSize {
+					binary.LittleEndian.PutUint32(out[hdr+8:], uint32(len(payload)))
+				}
+				binary.LittleEndian.PutUint32(out[dirOffset+4:], uint32(len(payload)))
+				img.finish(out)
+				return out, nil
+			}
+		}
+	}
+
+	// Добавление новой секции в конец образа
+	n := len(img.file.Sections)
+	newHdr := img.sectionTableOff + n*peSectionHeaderSize
+	firstRaw := uint32(len(out))
+	for _, s := range img.file.Sections {
+		if s.Offset != 0 && s.Offset < firstRaw {
+			firstRaw = s.Offset
+		}
+	}
+	if uint32(newHdr+peSectionHeaderSize) > img.sizeOfHeaders || uint32(newHdr+peSectionHeaderSize) > firstRaw {
+		return nil, fmt.Errorf("no room for an additional section header in PE file")
+	}
+
+	var imageEnd uint32
+	for _, s := range img.file.Sections {
+		size := s.VirtualSize
+		if size == 0 {
+			size = s.Size
+		}
+		if end := s.VirtualAddress + size; end > imageEnd {
+			imageEnd = end
+		}
+	}
+	va := alignUp(imageEnd, img.sectionAlignment)
+
+	payload, _ := rs.serialize(va)
+	rawOffset := alignUp(uint32(len(out)), img.fileAlignment)
+	rawSize := alignUp(uint32(len(payload)), img.fileAlignment)
+	grown := make([]byte, rawOffset+rawSize)
+	copy(grown, out)
+	copy(grown[rawOffset:], payload)
+	out = grown
+
+	hdr := out[newHdr : newHdr+peSectionHeaderSize]
+	copy(hdr[0:8], ".rsrc\x00\x00\x00")
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(hdr[12:], va)
+	binary.LittleEndian.PutUint32(hdr[16:], rawSize)
+	binary.LittleEndian.PutUint32(hdr[20:], rawOffset)
+	binary.LittleEndian.PutUint32(hdr[36:], peResourceCharacteristics)
+
+	peOffset := int(binary.LittleEndian.Uint32(out[0x3c:]))
+	binary.LittleEndian.PutUint16(out[peOffset+4+2:], uint16(n+1))
+
+	// Прежняя секция ресурсов переименовывается, чтобы инструменты,
+	// ищущие .rsrc по имени, не находили устаревшие данные
+	if rva != 0 {
+		if idx, s := img.sectionFor(rva); s != nil && s.Name == ".rsrc" {
+			copy(out[img.sectionTableOff+idx*peSectionHeaderSize:], ".oldrsrc")
+		}
+	}
+
+	binary.LittleEndian.PutUint32(out[dirOffset:], va)
+	binary.LittleEndian.PutUint32(out[dirOffset+4:], uint32(len(payload)))
+	img.finish(out)
+	return out, nil
+}
+
+// finish пересчитывает SizeOfImage по таблице секций и контрольную сумму,
+// если она была задана в исходном файле
+func (img *peImage) finish(out []byte) {
+	peOffset := int(binary.LittleEndian.Uint32(out[0x3c:]))
+	sections := int(binary.LittleEndian.Uint16(out[peOffset+4+2:]))
+	var imageEnd uint32
+	for i := 0; i < sections; i++ {
+		hdr := out[img.sectionTableOff+i*peSectionHeaderSize:]
+		size := binary.LittleEndian.Uint32(hdr[8:])
+		if size == 0 {
+			size = binary.LittleEndian.Uint32(hdr[16:])
+		}
+		if end := binary.LittleEndian.Uint32(hdr[12:]) + size; end > imageEnd {
+			imageEnd = end
+		}
+	}
+	binary.LittleEndian.PutUint32(out[img.optionalOffset+56:], alignUp(imageEnd, img.sectionAlignment))
+
+	checksumOffset := img.optionalOffset + 64
+	if binary.LittleEndian.Uint32(img.data[checksumOffset:]) == 0 {
+		return
+	}
+	binary.LittleEndian.PutUint32(out[checksumOffset:], peChecksum(out, checksumOffset))
+}
+
+// peChecksum вычисляет контрольную сумму образа так же, как CheckSumMappedFile
+func peChecksum(data []byte, checksumOffset int) uint32 {
+	var sum uint64
+	for i := 0; i+1 < len(data); i += 2 {
+		if i == checksumOffset || i == checksumOffset+2 {
+			continue
+		}
+		sum += uint64(binary.LittleEndian.Uint16(data[i:]))
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	if len(data)%2 == 1 {
+		sum += uint64(data[len(data)-1])
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	sum = (sum & 0xffff) + (sum >> 16)
+	return uint32(sum) + uint32(len(data))
+}
@@ -0,0 +1,114 @@
+package mkversions
+
+import (
+	"debug/pe"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Фикстуры testdata собраны вручную: plain.exe - PE32+ с единственной
+// секцией .text, rsrc.exe - то же с секцией .rsrc на 2 КБ, содержащей
+// RT_MANIFEST, и заданной контрольной суммой.
+
+// writeFixture копирует фикстуру во временный каталог и записывает в нее VERSIONINFO
+func writeFixture(t *testing.T, name string, vr *VersionResource) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVersionResource(path, vr); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sectionNames(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := pe.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	for _, s := range f.Sections {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func checkVersionResource(t *testing.T, path string, want *VersionResource) {
+	t.Helper()
+	got, err := ReadExeMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadExeMetadata mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestWriteVersionResourceInPlace(t *testing.T) {
+	vr := testVersionResource()
+	path := writeFixture(t, "rsrc.exe", vr)
+
+	if names := sectionNames(t, path); !reflect.DeepEqual(names, []string{".text", ".rsrc"}) {
+		t.Errorf("sections = %v, want resources rewritten in place", names)
+	}
+	checkVersionResource(t, path, vr)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := parsePEImage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := img.resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.find(resourceID{ID: rtManifest}) == nil {
+		t.Error("existing RT_MANIFEST resource was lost")
+	}
+	checksumOffset := img.optionalOffset + 64
+	if got, want := img.file.OptionalHeader.(*pe.OptionalHeader64).CheckSum, peChecksum(data, checksumOffset); got != want {
+		t.Errorf("CheckSum = %#x, want %#x", got, want)
+	}
+	checkGolden(t, "rsrc.golden.exe", data)
+}
+
+func TestWriteVersionResourceAppendSection(t *testing.T) {
+	vr := testVersionResource()
+	path := writeFixture(t, "plain.exe", vr)
+
+	if names := sectionNames(t, path); !reflect.DeepEqual(names, []string{".text", ".rsrc"}) {
+		t.Errorf("sections = %v, want an appended .rsrc section", names)
+	}
+	checkVersionResource(t, path, vr)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "plain.golden.exe", data)
+
+	// Повторная запись помещается в добавленную секцию
+	if err := WriteVersionResource(path, vr); err != nil {
+		t.Fatal(err)
+	}
+	again, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Error("rewriting the same VERSIONINFO changed the file")
+	}
+}
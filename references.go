@@ -0,0 +1,250 @@
+package mkversions
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Reference - ссылка на задачу трекера, найденная в сообщении коммита
+type Reference struct {
+	// Type - имя шаблона, которым найдена ссылка, например "issue" или "jira"
+	Type string `json:"type"`
+	// ID - идентификатор задачи для подстановки в URL, например "123"
+	ID string `json:"id"`
+	// Text - ссылка в том виде, как она записана в сообщении, например "#123"
+	Text string `json:"text"`
+	// Action - "closes" для Fixes/Closes/Resolves, "refs" для Refs/See
+	Action string `json:"action,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// ReferencePattern описывает формат ссылок на задачи.
+// Первая группа Regexp (или группа с именем id) выделяет ID задачи,
+// URL - шаблон ссылки, в котором {id} заменяется на ID.
+type ReferencePattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	URL    string
+}
+
+// NewReferencePattern компилирует шаблон ссылок на задачи
+func NewReferencePattern(name, expr, urlTemplate string) (ReferencePattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ReferencePattern{}, fmt.Errorf("invalid reference pattern %s: %v", name, err)
+	}
+	return ReferencePattern{Name: name, Regexp: re, URL: urlTemplate}, nil
+}
+
+// IssueReferencePattern находит ссылки вида #123 (GitHub, GitLab, Gitea)
+func IssueReferencePattern(urlTemplate string) ReferencePattern {
+	return ReferencePattern{Name: "issue", Regexp: issueReferenceRe, URL: urlTemplate}
+}
+
+// JiraReferencePattern находит ключи задач Jira вида PROJ-456. Если projects
+// не заданы, подходит любой ключ из заглавных букв и цифр.
+func JiraReferencePattern(urlTemplate string, projects ...string) ReferencePattern {
+	re := jiraReferenceRe
+	if len(projects) > 0 {
+		quoted := make([]string, len(projects))
+		for i, p := range projects {
+			quoted[i] = regexp.QuoteMeta(p)
+		}
+		re = regexp.MustCompile(`\b((?:` + strings.Join(quoted, "|") + `)-[0-9]+)\b`)
+	}
+	return ReferencePattern{Name: "jira", Regexp: re, URL: urlTemplate}
+}
+
+// DefaultReferencePatterns возвращает шаблоны по умолчанию: только #123 без URL
+func DefaultReferencePatterns() []ReferencePattern {
+	return []ReferencePattern{IssueReferencePattern("")}
+}
+
+var (
+	issueReferenceRe = regexp.MustCompile(`\B#([0-9]+)\b`)
+	jiraReferenceRe  = regexp.MustCompile(`\b([A-Z][A-Z0-9]+-[0-9]+)\b`)
+
+	referenceActionRe    = regexp.MustCompile(`(?i)\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?|refs?|see)\b:?\s*$`)
+	referenceSeparatorRe = regexp.MustCompile(`(?i)^(?:\s*,\s*|\s+and\s+|\s+)$`)
+)
+
+// id возвращает ID задачи из найденного совпадения
+func (p *ReferencePattern) id(text string, loc []int) string {
+	group := 1
+	if i := p.Regexp.SubexpIndex("id"); i > 0 {
+		group = i
+	}
+	if 2*group+1 < len(loc) && loc[2*group] >= 0 {
+		return text[loc[2*group]:loc[2*group+1]]
+	}
+	return text[loc[0]:loc[1]]
+}
+
+// link подставляет id в шаблон URL
+func (p *ReferencePattern) link(id string) string {
+	if p.URL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(p.URL, "{id}", id)
+}
+
+type referenceMatch struct {
+	start, end int
+	ref        Reference
+}
+
+// findReferences находит непересекающиеся ссылки в text в порядке следования.
+// При совпадении начала приоритет у шаблона, заданного раньше.
+func findReferences(text string, patterns []ReferencePattern) []referenceMatch {
+	var matches []referenceMatch
+	for i := range patterns {
+		p := &patterns[i]
+		if p.Regexp == nil {
+			continue
+		}
+		for _, loc := range p.Regexp.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			id := p.id(text, loc)
+			matches = append(matches, referenceMatch{
+				start: loc[0],
+				end:   loc[1],
+				ref:   Reference{Type: p.Name, ID: id, Text: text[loc[0]:loc[1]], URL: p.link(id)},
+			})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var result []referenceMatch
+	end := 0
+	for _, m := range matches {
+		if m.start < end {
+			continue
+		}
+		result = append(result, m)
+		end = m.end
+	}
+	return result
+}
+
+// referenceAction определяет действие по ключевому слову перед ссылкой.
+// Списки вида "Fixes #1, #2 and #3" наследуют действие первой ссылки.
+func referenceAction(text string, matches []referenceMatch, i int) string {
+	m := matches[i]
+	lineStart := strings.LastIndexByte(text[:m.start], '\n') + 1
+	if i > 0 && matches[i-1].end >= lineStart && referenceSeparatorRe.MatchString(text[matches[i-1].end:m.start]) {
+		return matches[i-1].ref.Action
+	}
+
+	keyword := referenceActionRe.FindStringSubmatch(text[lineStart:m.start])
+	if keyword == nil {
+		return ""
+	}
+	switch k := strings.ToLower(keyword[1]); {
+	case strings.HasPrefix(k, "ref"), k == "see":
+		return "refs"
+	}
+	return "closes"
+}
+
+// parseReferences извлекает ссылки на задачи из сообщения коммита.
+// Повторы одной задачи объединяются.
+func parseReferences(message string, patterns []ReferencePattern) []Reference {
+	matches := findReferences(message, patterns)
+	var refs []Reference
+	index := map[string]int{}
+	for i := range matches {
+		matches[i].ref.Action = referenceAction(message, matches, i)
+		ref := matches[i].ref
+		key := ref.Type + "\x00" + ref.ID
+		if j, ok := index[key]; ok {
+			if refs[j].Action == "" {
+				refs[j].Action = ref.Action
+			}
+			continue
+		}
+		index[key] = len(refs)
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// ExtractReferences заполняет References коммитов журнала по patterns;
+// nil означает DefaultReferencePatterns. Эти же шаблоны используются
+// для ссылок в ToMarkdown и ToHTML.
+func (cl *Changelog) ExtractReferences(patterns []ReferencePattern) {
+	if patterns == nil {
+		patterns = DefaultReferencePatterns()
+	}
+	cl.patterns = patterns
+	for i := range cl.Commits {
+		c := &cl.Commits[i]
+		message := c.Message
+		if c.Body != "" {
+			message += "\n\n" + c.Body
+		}
+		c.References = parseReferences(message, patterns)
+	}
+}
+
+// HasReference сообщает, ссылается ли коммит на задачу id.
+// id сравнивается без учета регистра с ID или текстом ссылки: "123", "#123", "PROJ-456".
+func (c *CommitDetails) HasReference(id string) bool {
+	for _, ref := range c.References {
+		if strings.EqualFold(ref.ID, id) || strings.EqualFold(ref.Text, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByReference возвращает журнал из коммитов, ссылающихся на любую из задач ids
+func (cl *Changelog) FilterByReference(ids ...string) *Changelog {
+	filtered := &Changelog{patterns: cl.patterns}
+	for i, c := range cl.Commits {
+		for _, id := range ids {
+			if !c.HasReference(id) {
+				continue
+			}
+			filtered.Commits = append(filtered.Commits, c)
+			if i < len(cl.Entries) {
+				filtered.Entries = append(filtered.Entries, cl.Entries[i])
+			}
+			break
+		}
+	}
+	return filtered
+}
+
+// linkReferences заменяет ссылки на задачи в text результатом link,
+// а остальной текст пропускает через escape. Ссылки без URL остаются текстом.
+func linkReferences(text string, patterns []ReferencePattern, link func(text, url string) string, escape func(string) string) string {
+	var sb strings.Builder
+	pos := 0
+	for _, m := range findReferences(text, patterns) {
+		if m.ref.URL == "" {
+			continue
+		}
+		sb.WriteString(escape(text[pos:m.start]))
+		sb.WriteString(link(m.ref.Text, m.ref.URL))
+		pos = m.end
+	}
+	sb.WriteString(escape(text[pos:]))
+	return sb.String()
+}
+
+func markdownReferences(text string, patterns []ReferencePattern) string {
+	return linkReferences(text, patterns, func(text, url string) string {
+		return "[" + text + "](" + url + ")"
+	}, func(s string) string { return s })
+}
+
+func htmlReferences(text string, patterns []ReferencePattern) string {
+	return linkReferences(text, patterns, func(text, url string) string {
+		return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(text) + "</a>"
+	}, html.EscapeString)
+}
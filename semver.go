@@ -0,0 +1,215 @@
+package mkversions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer описывает версию в формате Semantic Versioning 2.0.0
+type SemVer struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// ParseSemVer разбирает строку версии строго по спецификации SemVer 2.0.0
+func ParseSemVer(version string) (*SemVer, error) {
+	if version == "" {
+		return nil, fmt.Errorf("invalid semantic version: empty string")
+	}
+
+	rest := version
+	var build, prerelease string
+	if idx := strings.IndexByte(rest, '+'); idx != -1 {
+		build = rest[idx+1:]
+		rest = rest[:idx]
+		if build == "" {
+			return nil, fmt.Errorf("invalid semantic version %q: empty build metadata", version)
+		}
+	}
+	if idx := strings.IndexByte(rest, '-'); idx != -1 {
+		prerelease = rest[idx+1:]
+		rest = rest[:idx]
+		if prerelease == "" {
+			return nil, fmt.Errorf("invalid semantic version %q: empty pre-release", version)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", version)
+	}
+
+	sv := &SemVer{}
+	nums := []*uint64{&sv.Major, &sv.Minor, &sv.Patch}
+	for i, part := range parts {
+		if !isNumericIdentifier(part) {
+			return nil, fmt.Errorf("invalid semantic version %q: bad numeric component %q", version, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: %v", version, err)
+		}
+		*nums[i] = n
+	}
+
+	if prerelease != "" {
+		sv.Prerelease = strings.Split(prerelease, ".")
+		for _, id := range sv.Prerelease {
+			if !isAlphanumericIdentifier(id) {
+				return nil, fmt.Errorf("invalid semantic version %q: bad pre-release identifier %q", version, id)
+			}
+			if isDigits(id) && !isNumericIdentifier(id) {
+				return nil, fmt.Errorf("invalid semantic version %q: pre-release identifier %q has leading zero", version, id)
+			}
+		}
+	}
+
+	if build != "" {
+		sv.Build = strings.Split(build, ".")
+		for _, id := range sv.Build {
+			if !isAlphanumericIdentifier(id) {
+				return nil, fmt.Errorf("invalid semantic version %q: bad build identifier %q", version, id)
+			}
+		}
+	}
+
+	return sv, nil
+}
+
+// MustParseSemVer работает как ParseSemVer, но паникует при ошибке
+func MustParseSemVer(version string) *SemVer {
+	sv, err := ParseSemVer(version)
+	if err != nil {
+		panic(err)
+	}
+	return sv
+}
+
+// IsValidSemVer сообщает, является ли строка корректной версией SemVer 2.0.0
+func IsValidSemVer(version string) bool {
+	_, err := ParseSemVer(version)
+	return err == nil
+}
+
+func (v *SemVer) String() string {
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatUint(v.Major, 10))
+	sb.WriteByte('.')
+	sb.WriteString(strconv.FormatUint(v.Minor, 10))
+	sb.WriteByte('.')
+	sb.WriteString(strconv.FormatUint(v.Patch, 10))
+	if len(v.Prerelease) > 0 {
+		sb.WriteByte('-')
+		sb.WriteString(strings.Join(v.Prerelease, "."))
+	}
+	if len(v.Build) > 0 {
+		sb.WriteByte('+')
+		sb.WriteString(strings.Join(v.Build, "."))
+	}
+	return sb.String()
+}
+
+// IsPrerelease сообщает, содержит ли версия pre-release часть
+func (v *SemVer) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare сравнивает версии по правилам приоритета SemVer.
+// Возвращает -1, 0 или 1; метаданные сборки не учитываются.
+func (v *SemVer) Compare(other *SemVer) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// Версия без pre-release имеет больший приоритет
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := comparePrereleaseIdentifier(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(other.Prerelease)))
+}
+
+// LessThan сообщает, имеет ли версия меньший приоритет, чем other
+func (v *SemVer) LessThan(other *SemVer) bool {
+	return v.Compare(other) < 0
+}
+
+// Equal сообщает, равны ли версии по приоритету
+func (v *SemVer) Equal(other *SemVer) bool {
+	return v.Compare(other) == 0
+}
+
+func comparePrereleaseIdentifier(a, b string) int {
+	aNum, bNum := isDigits(a), isDigits(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return compareUint(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumericIdentifier(s string) bool {
+	return isDigits(s) && (s == "0" || s[0] != '0')
+}
+
+func isAlphanumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package mkversions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		version string
+		want    *SemVer
+	}{
+		{"0.0.0", &SemVer{}},
+		{"1.2.3", &SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{"10.20.30", &SemVer{Major: 10, Minor: 20, Patch: 30}},
+		{"1.0.0-alpha", &SemVer{Major: 1, Prerelease: []string{"alpha"}}},
+		{"1.0.0-alpha.1", &SemVer{Major: 1, Prerelease: []string{"alpha", "1"}}},
+		{"1.0.0-0.3.7", &SemVer{Major: 1, Prerelease: []string{"0", "3", "7"}}},
+		{"1.0.0-x-y-z.--", &SemVer{Major: 1, Prerelease: []string{"x-y-z", "--"}}},
+		{"1.0.0-alpha+001", &SemVer{Major: 1, Prerelease: []string{"alpha"}, Build: []string{"001"}}},
+		{"1.0.0+20130313144700", &SemVer{Major: 1, Build: []string{"20130313144700"}}},
+		{"1.0.0-beta+exp.sha.5114f85", &SemVer{Major: 1, Prerelease: []string{"beta"}, Build: []string{"exp", "sha", "5114f85"}}},
+		{"1.0.0+21AF26D3---117B344092BD", &SemVer{Major: 1, Build: []string{"21AF26D3---117B344092BD"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSemVer(tt.version)
+		if err != nil {
+			t.Errorf("ParseSemVer(%q): %v", tt.version, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSemVer(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+		if got.String() != tt.version {
+			t.Errorf("ParseSemVer(%q).String() = %q", tt.version, got.String())
+		}
+	}
+}
+
+func TestParseSemVerInvalid(t *testing.T) {
+	for _, version := range []string{
+		"",
+		// Префикс "v" - часть тега, а не версии; его снимает tagPrefix
+		"v1.2.3",
+		"V1.2.3",
+		"1",
+		"1.2",
+		"1.2.3.4",
+		// Ведущие нули запрещены в числах и числовых идентификаторах pre-release
+		"01.2.3",
+		"1.02.3",
+		"1.2.03",
+		"1.2.3-01",
+		"1.2.3-alpha.01",
+		"1.2.3-",
+		"1.2.3+",
+		"1.2.3-alpha..1",
+		"1.2.3+build..1",
+		"1.2.3-alpha_beta",
+		"1.2.3+build!",
+		"-1.2.3",
+		"1.2.-3",
+		" 1.2.3",
+		"99999999999999999999.0.0",
+	} {
+		if v, err := ParseSemVer(version); err == nil {
+			t.Errorf("ParseSemVer(%q) = %v, want error", version, v)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	// Пример порядка из спецификации SemVer 2.0.0 с дополнениями
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-0",
+		"1.0.0-2",
+		"1.0.0-10",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := MustParseSemVer(ordered[i]), MustParseSemVer(ordered[j])
+			want := compareUint(uint64(i), uint64(j))
+			if got := a.Compare(b); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestSemVerCompareIgnoresBuild(t *testing.T) {
+	tests := [][2]string{
+		{"1.0.0+build.1", "1.0.0+build.2"},
+		{"1.0.0", "1.0.0+20130313144700"},
+		{"1.0.0-alpha+a", "1.0.0-alpha+b"},
+	}
+	for _, tt := range tests {
+		a, b := MustParseSemVer(tt[0]), MustParseSemVer(tt[1])
+		if !a.Equal(b) || a.LessThan(b) || b.LessThan(a) {
+			t.Errorf("%s and %s differ in precedence, want equal", tt[0], tt[1])
+		}
+	}
+}
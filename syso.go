@@ -0,0 +1,191 @@
+package mkversions
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+const (
+	coffFileHeaderSize = 20
+	coffRelocationSize = 10
+	coffSymbolSize     = 18
+
+	imageRelAMD64Addr32NB = 0x0003
+	imageRelI386Dir32NB   = 0x0007
+	imageRelARM64Addr32NB = 0x0002
+
+	imageSymClassStatic = 3
+)
+
+// sysoMachines сопоставляет GOARCH типу машины COFF и типу релокации RVA
+var sysoMachines = map[string]struct {
+	machine uint16
+	reloc   uint16
+}{
+	"amd64": {0x8664, imageRelAMD64Addr32NB},
+	"386":   {0x014c, imageRelI386Dir32NB},
+	"arm64": {0xaa64, imageRelARM64Addr32NB},
+}
+
+// WriteSyso записывает объектный файл COFF (.syso) с ресурсами VERSIONINFO,
+// значком (AppMetadata.IconFile) и манифестом (AppMetadata.ManifestFile).
+// go build компонует такой файл в исполняемый файл Windows автоматически.
+// Для одинаковых входных данных результат побайтно совпадает.
+func (i *Info) WriteSyso(path, arch string) error {
+	rs, err := i.windowsResources()
+	if err != nil {
+		return err
+	}
+	obj, err := rs.coffObject(arch)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, obj, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// windowsResources собирает набор ресурсов для Windows из Info
+func (i *Info) windowsResources() (*resourceSet, error) {
+	vr := i.VersionResource()
+	lang := vr.Language()
+
+	rs := &resourceSet{}
+	rs.add(&resourceEntry{
+		Type: resourceID{ID: rtVersion},
+		Name: resourceID{ID: 1},
+		Lang: lang,
+		Data: vr.Bytes(),
+	})
+
+	if i.AppMetadata == nil {
+		return rs, nil
+	}
+
+	if i.IconFile != "" {
+		data, err := os.ReadFile(i.IconFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read icon: %v", err)
+		}
+		if err := addIconResources(rs, data, lang); err != nil {
+			return nil, fmt.Errorf("failed to parse icon %s: %v", i.IconFile, err)
+		}
+	}
+
+	if i.ManifestFile != "" {
+		data, err := os.ReadFile(i.ManifestFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %v", err)
+		}
+		rs.add(&resourceEntry{
+			Type: resourceID{ID: rtManifest},
+			Name: resourceID{ID: 1},
+			Lang: lang,
+			Data: data,
+		})
+	}
+	return rs, nil
+}
+
+// addIconResources разбирает .ico файл на ресурсы RT_ICON и группу RT_GROUP_ICON
+func addIconResources(rs *resourceSet, ico []byte, lang uint16) error {
+	if len(ico) < 6 || binary.LittleEndian.Uint16(ico[0:]) != 0 || binary.LittleEndian.Uint16(ico[2:]) != 1 {
+		return fmt.Errorf("not an ICO file")
+	}
+	count := int(binary.LittleEndian.Uint16(ico[4:]))
+	if count == 0 || len(ico) < 6+count*16 {
+		return fmt.Errorf("truncated ICO directory")
+	}
+
+	var group bytes.Buffer
+	group.Write(ico[0:6])
+	for n := 0; n < count; n++ {
+		entry := ico[6+n*16 : 6+(n+1)*16]
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		if uint64(offset)+uint64(size) > uint64(len(ico)) {
+			return fmt.Errorf("icon image %d is out of range", n)
+		}
+
+		id := uint16(n + 1)
+		rs.add(&resourceEntry{
+			Type: resourceID{ID: rtIcon},
+			Name: resourceID{ID: id},
+			Lang: lang,
+			Data: append([]byte(nil), ico[offset:offset+size]...),
+		})
+
+		// GRPICONDIRENTRY повторяет ICONDIRENTRY, но вместо смещения хранит ID ресурса
+		group.Write(entry[:12])
+		binary.Write(&group, binary.LittleEndian, id)
+	}
+
+	rs.add(&resourceEntry{
+		Type: resourceID{ID: rtGroupIcon},
+		Name: resourceID{ID: 1},
+		Lang: lang,
+		Data: group.Bytes(),
+	})
+	return nil
+}
+
+// coffObject упаковывает ресурсы в объектный файл COFF с одной секцией .rsrc
+func (rs *resourceSet) coffObject(arch string) ([]byte, error) {
+	target, ok := sysoMachines[arch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported syso architecture %q", arch)
+	}
+
+	data, relocs := rs.serialize(0)
+
+	dataOffset := uint32(coffFileHeaderSize + peSectionHeaderSize)
+	relocOffset := dataOffset + uint32(len(data))
+	symbolOffset := relocOffset + uint32(len(relocs))*coffRelocationSize
+
+	var b bytes.Buffer
+	le := binary.LittleEndian
+
+	// IMAGE_FILE_HEADER
+	binary.Write(&b, le, target.machine)
+	binary.Write(&b, le, uint16(1))
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, symbolOffset)
+	binary.Write(&b, le, uint32(1))
+	binary.Write(&b, le, uint16(0))
+	binary.Write(&b, le, uint16(0))
+
+	// IMAGE_SECTION_HEADER
+	b.WriteString(".rsrc\x00\x00\x00")
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, uint32(len(data)))
+	binary.Write(&b, le, dataOffset)
+	binary.Write(&b, le, relocOffset)
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, uint16(len(relocs)))
+	binary.Write(&b, le, uint16(0))
+	binary.Write(&b, le, uint32(peResourceCharacteristics))
+
+	b.Write(data)
+
+	// Релокации полей OffsetToData относительно начала секции
+	for _, off := range relocs {
+		binary.Write(&b, le, off)
+		binary.Write(&b, le, uint32(0))
+		binary.Write(&b, le, target.reloc)
+	}
+
+	// Символ секции .rsrc и пустая таблица строк
+	b.WriteString(".rsrc\x00\x00\x00")
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, uint16(1))
+	binary.Write(&b, le, uint16(0))
+	b.WriteByte(imageSymClassStatic)
+	b.WriteByte(0)
+	binary.Write(&b, le, uint32(4))
+
+	return b.Bytes(), nil
+}
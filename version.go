@@ -1,11 +1,13 @@
 package mkversions
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -23,13 +25,46 @@ type Info struct {
 	Architecture    string
 	Developer       string
 	Dependencies    map[string]string
+	DependencySums  map[string]string
+	BuildSettings   map[string]string
 	DetailedVersion string
+	ValidVersion    bool
+	// Module - каталог модуля относительно корня репозитория, см. WithModule
+	Module string `json:",omitempty"`
+	// Warnings перечисляет поля, оставшиеся со значениями по умолчанию, и причины
+	Warnings []string `json:",omitempty"`
 	*GITInfo
 	*AppMetadata
+
+	versionFromGit bool
+	tagPrefix      string
+	dirtyExclude   []string
+	logger         *slog.Logger
+	gitTimeout     *time.Duration
+	gitRunner      GitRunner
+	changelogRange bool
+	changelogStats bool
+	moduleSet      bool
+	// branchRef - ветка из WithBranchName для запросов git
+	branchRef string
+
+	referencePatterns []ReferencePattern
 }
 
 // Функция создания Info
 func NewInfo(version, releaseType, developer string, opts ...Option) *Info {
+	info, _ := NewInfoE(version, releaseType, developer, opts...)
+	return info
+}
+
+// NewInfoE работает как NewInfo, но возвращает ошибки получения данных из git.
+// Info возвращается и при ошибке: недоступные поля остаются значениями по умолчанию.
+func NewInfoE(version, releaseType, developer string, opts ...Option) (*Info, error) {
+	return NewInfoContext(context.Background(), version, releaseType, developer, opts...)
+}
+
+// NewInfoContext работает как NewInfoE; ctx ограничивает все вызовы git
+func NewInfoContext(ctx context.Context, version, releaseType, developer string, opts ...Option) (*Info, error) {
 	var branchName string
 	var commitHash string
 	var commitDate time.Time
@@ -59,6 +94,7 @@ func NewInfo(version, releaseType, developer string, opts ...Option) *Info {
 			Changelog:       nil,
 		},
 		AppMetadata: &AppMetadata{},
+		tagPrefix:   "v",
 	}
 
 	// Применение опций
@@ -66,8 +102,15 @@ func NewInfo(version, releaseType, developer string, opts ...Option) *Info {
 		opt(info)
 	}
 
-	info.PrepareGit()
-	return info
+	err := info.PrepareGitContext(ctx)
+	info.DetailedVersion = info.detailedVersion()
+	info.ValidVersion = IsValidSemVer(info.Version)
+	return info, err
+}
+
+// detailedVersion строит DetailedVersion из итоговых версии, коммита и даты сборки
+func (info *Info) detailedVersion() string {
+	return fmt.Sprintf("%s-%s(%s) | %s", info.Version, info.GITInfo.CommitHashShort, info.ReleaseType, info.BuildDate.Format("2006-01-02"))
 }
 
 func (i *Info) SetInfo(opts ...Option) *Info {
@@ -80,13 +123,10 @@ func (i *Info) SetInfo(opts ...Option) *Info {
 
 // NewInfo создает новый объект Info с заданной версией и коммитом
 func NewInfoCustom(version, commit, commitFull, releaseType, developer, branch string, commitDate time.Time) *Info {
-	dep, err := getDependencies()
-	if err != nil {
-		fmt.Println("Error while getting dependencies: ", err)
-	}
+	dep, depErr := getDependencies(DefaultLogger())
 
 	buildDate := time.Now().Format("2006-01-02")
-	return &Info{
+	info := &Info{
 		Version:         version,
 		BuildDate:       time.Now(),
 		GoVersion:       runtime.Version(),
@@ -97,6 +137,7 @@ func NewInfoCustom(version, commit, commitFull, releaseType, developer, branch s
 		Dependencies:    dep,
 		Developer:       developer,
 		DetailedVersion: fmt.Sprintf("%s-%s(%s) | %s", version, commit, releaseType, buildDate),
+		ValidVersion:    IsValidSemVer(version),
 		GITInfo: &GITInfo{
 			CommitHash:      commitFull,
 			CommitHashShort: commit,
@@ -104,6 +145,10 @@ func NewInfoCustom(version, commit, commitFull, releaseType, developer, branch s
 			CommitDate:      commitDate,
 		},
 	}
+	if depErr != nil {
+		info.addWarning("Dependencies", depErr)
+	}
+	return info
 }
 
 // generateBuildID создает уникальный идентификатор для сборки
@@ -116,10 +161,11 @@ func generateBuildID() string {
 	return hex.EncodeToString(bytes)
 }
 
-func getDependencies() (map[string]string, error) {
+func getDependencies(logger *slog.Logger) (map[string]string, error) {
 	cmd := exec.Command("go", "list", "-m", "-json", "all")
 	output, err := cmd.Output()
 	if err != nil {
+		logger.Warn("failed to list Go modules", slog.Any("error", err))
 		return nil, err
 	}
 
@@ -135,6 +181,9 @@ func getDependencies() (map[string]string, error) {
 			Version string `json:"Version"`
 		}
 		if err := decoder.Decode(&module); err != nil {
+			if err != io.EOF {
+				logger.Warn("failed to decode Go module list", slog.Any("error", err))
+			}
 			break
 		}
 		modules = append(modules, module)
@@ -159,6 +208,31 @@ func (info *Info) RemoveDependency(modulePath string) {
 	delete(info.Dependencies, modulePath)
 }
 
+// ParsedVersion разбирает Version как SemVer 2.0.0
+func (info *Info) ParsedVersion() (*SemVer, error) {
+	return ParseSemVer(info.Version)
+}
+
+// Compare сравнивает версии двух сборок по правилам приоритета SemVer
+func (info *Info) Compare(other *Info) (int, error) {
+	v, err := info.ParsedVersion()
+	if err != nil {
+		return 0, err
+	}
+	o, err := other.ParsedVersion()
+	if err != nil {
+		return 0, err
+	}
+	return v.Compare(o), nil
+}
+
+// IsNewerThan сообщает, новее ли версия сборки, чем у other.
+// Некорректные версии никогда не считаются более новыми.
+func (info *Info) IsNewerThan(other *Info) bool {
+	c, err := info.Compare(other)
+	return err == nil && c > 0
+}
+
 func (info *Info) SaveToHistory(bh *BuildHistory) {
 	bh.AddBuild(info)
 }
@@ -166,8 +240,8 @@ func (info *Info) SaveToHistory(bh *BuildHistory) {
 // String возвращает информацию о версии в формате строки
 func (info *Info) String() string {
 	return fmt.Sprintf(
-		"Version: %s\nBuild Date: %s\nCommit: %s\nGo Version: %s\nPlatform: %s\nArchitecture: %s\nBuild ID: %s\nRelease Type: %s\nDeveloper: %s\nDetailed Version: %s\nDependencies: %v",
-		info.Version, info.BuildDate, info.CommitHash, info.GoVersion, info.Platform, info.Architecture, info.BuildID, info.ReleaseType, info.Developer, info.DetailedVersion, info.Dependencies,
+		"Version: %s\nBuild Date: %s\nCommit: %s\nDirty: %t\nModified Files: %v\nUntracked Files: %v\nDiff Hash: %s\nGo Version: %s\nPlatform: %s\nArchitecture: %s\nBuild ID: %s\nRelease Type: %s\nDeveloper: %s\nDetailed Version: %s\nDependencies: %v",
+		info.Version, info.BuildDate, info.CommitHash, info.IsDirty, info.ModifiedFiles, info.UntrackedFiles, info.DiffHash, info.GoVersion, info.Platform, info.Architecture, info.BuildID, info.ReleaseType, info.Developer, info.DetailedVersion, info.Dependencies,
 	)
 }
 
@@ -177,6 +251,10 @@ func (info *Info) ToMarkdown() string {
 			"* **Version:** %s\n"+
 			"* **Build Date:** %s\n"+
 			"* **Commit Hash:** %s\n"+
+			"* **Dirty:** %t\n"+
+			"* **Modified Files:** %v\n"+
+			"* **Untracked Files:** %v\n"+
+			"* **Diff Hash:** %s\n"+
 			"* **Go Version:** %s\n"+
 			"* **Platform:** %s\n"+
 			"* **Architecture:** %s\n"+
@@ -185,15 +263,17 @@ func (info *Info) ToMarkdown() string {
 			"* **Developer:** %s\n"+
 			"* **Detailed Version:** %s\n"+
 			"* **Dependencies:** %v",
-		info.Version, info.BuildDate, info.CommitHash, info.GoVersion, info.Platform, info.Architecture, info.BuildID, info.ReleaseType, info.Developer, info.DetailedVersion, info.Dependencies,
+		info.Version, info.BuildDate, info.CommitHash, info.IsDirty, info.ModifiedFiles, info.UntrackedFiles, info.DiffHash, info.GoVersion, info.Platform, info.Architecture, info.BuildID, info.ReleaseType, info.Developer, info.DetailedVersion, info.Dependencies,
 	)
 }
 
-// JSON возвращает информацию о версии в формате JSON
+// JSON возвращает информацию о версии в формате JSON.
+// При ошибке кодирования возвращается пустая строка, а ошибка записывается в лог.
 func (info *Info) JSON() string {
 	data, err := json.Marshal(info)
 	if err != nil {
-		log.Fatalf("failed to marshal version info to JSON: %v", err)
+		info.log().Error("failed to marshal version info to JSON", slog.Any("error", err))
+		return ""
 	}
 	return string(data)
 }
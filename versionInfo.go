@@ -0,0 +1,326 @@
+package mkversions
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	vsFixedFileInfoSignature = 0xFEEF04BD
+	vsFixedFileInfoSize      = 52
+
+	vsFFPrerelease = 0x00000002
+	vosNTWindows32 = 0x00040004
+	vftApp         = 0x00000001
+
+	// Английский (США), Unicode
+	defaultLanguage = 0x0409
+	defaultCodePage = 1200
+)
+
+// VersionTranslation задает язык и кодовую страницу блока StringFileInfo.
+// Strings переопределяет строки, общие для всех языков.
+type VersionTranslation struct {
+	Language uint16
+	CodePage uint16
+	Strings  map[string]string `json:",omitempty"`
+}
+
+func (t VersionTranslation) key() string {
+	return fmt.Sprintf("%04X%04X", t.Language, t.CodePage)
+}
+
+// VersionStringTable - строки одного языка в StringFileInfo
+type VersionStringTable struct {
+	Language uint16
+	CodePage uint16
+	Strings  map[string]string
+}
+
+// VersionResource описывает ресурс VERSIONINFO исполняемого файла Windows
+type VersionResource struct {
+	FileVersion    [4]uint16
+	ProductVersion [4]uint16
+	FileFlagsMask  uint32
+	FileFlags      uint32
+	FileOS         uint32
+	FileType       uint32
+	FileSubtype    uint32
+	StringTables   []VersionStringTable
+}
+
+// VersionResource строит ресурс VERSIONINFO из Version и AppMetadata
+func (i *Info) VersionResource() *VersionResource {
+	meta := i.AppMetadata
+	if meta == nil {
+		meta = &AppMetadata{}
+	}
+
+	productVersion := meta.ProductVersion
+	if productVersion == "" {
+		productVersion = i.Version
+	}
+	internalName := meta.InternalName
+	if internalName == "" {
+		internalName = meta.ProgramName
+	}
+
+	base := map[string]string{}
+	setString := func(key, value string) {
+		if value != "" {
+			base[key] = value
+		}
+	}
+	setString("CompanyName", meta.CompanyName)
+	setString("FileDescription", meta.Description)
+	setString("FileVersion", i.Version)
+	setString("InternalName", internalName)
+	setString("LegalCopyright", meta.Legal)
+	setString("ProductName", meta.ProgramName)
+	setString("ProductVersion", productVersion)
+
+	vr := &VersionResource{
+		FileVersion:    parseVersionQuad(i.Version),
+		ProductVersion: parseVersionQuad(productVersion),
+		FileFlagsMask:  0x3F,
+		FileOS:         vosNTWindows32,
+		FileType:       vftApp,
+	}
+	if sv, err := ParseSemVer(i.Version); err == nil && sv.IsPrerelease() {
+		vr.FileFlags |= vsFFPrerelease
+	}
+
+	translations := meta.Translations
+	if len(translations) == 0 {
+		translations = []VersionTranslation{{Language: defaultLanguage, CodePage: defaultCodePage}}
+	}
+	for _, t := range translations {
+		strs := make(map[string]string, len(base)+len(t.Strings))
+		for k, v := range base {
+			strs[k] = v
+		}
+		for k, v := range t.Strings {
+			strs[k] = v
+		}
+		vr.StringTables = append(vr.StringTables, VersionStringTable{Language: t.Language, CodePage: t.CodePage, Strings: strs})
+	}
+	return vr
+}
+
+// parseVersionQuad извлекает до четырех числовых компонентов из строки версии,
+// например "1.4.2-rc.1" дает 1.4.2.0
+func parseVersionQuad(version string) [4]uint16 {
+	var quad [4]uint16
+	version = strings.TrimPrefix(version, "v")
+	if idx := strings.IndexAny(version, "-+ "); idx != -1 {
+		version = version[:idx]
+	}
+	for i, part := range strings.SplitN(version, ".", 4) {
+		n, err := strconv.ParseUint(part, 10, 16)
+		if err != nil {
+			break
+		}
+		quad[i] = uint16(n)
+	}
+	return quad
+}
+
+// Language возвращает язык ресурса: язык первой таблицы строк
+func (vr *VersionResource) Language() uint16 {
+	if len(vr.StringTables) == 0 {
+		return defaultLanguage
+	}
+	return vr.StringTables[0].Language
+}
+
+// Bytes кодирует ресурс в двоичную структуру VS_VERSIONINFO
+func (vr *VersionResource) Bytes() []byte {
+	fixed := make([]byte, vsFixedFileInfoSize)
+	binary.LittleEndian.PutUint32(fixed[0:], vsFixedFileInfoSignature)
+	binary.LittleEndian.PutUint32(fixed[4:], 0x00010000)
+	binary.LittleEndian.PutUint32(fixed[8:], uint32(vr.FileVersion[0])<<16|uint32(vr.FileVersion[1]))
+	binary.LittleEndian.PutUint32(fixed[12:], uint32(vr.FileVersion[2])<<16|uint32(vr.FileVersion[3]))
+	binary.LittleEndian.PutUint32(fixed[16:], uint32(vr.ProductVersion[0])<<16|uint32(vr.ProductVersion[1]))
+	binary.LittleEndian.PutUint32(fixed[20:], uint32(vr.ProductVersion[2])<<16|uint32(vr.ProductVersion[3]))
+	binary.LittleEndian.PutUint32(fixed[24:], vr.FileFlagsMask)
+	binary.LittleEndian.PutUint32(fixed[28:], vr.FileFlags)
+	binary.LittleEndian.PutUint32(fixed[32:], vr.FileOS)
+	binary.LittleEndian.PutUint32(fixed[36:], vr.FileType)
+	binary.LittleEndian.PutUint32(fixed[40:], vr.FileSubtype)
+
+	var tables, translations [][]byte
+	var translationValue []byte
+	for _, st := range vr.StringTables {
+		keys := make([]string, 0, len(st.Strings))
+		for k := range st.Strings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var strs [][]byte
+		for _, k := range keys {
+			value := utf16z(st.Strings[k])
+			strs = append(strs, versionBlock(k, value, uint16(len(value)/2), 1, nil))
+		}
+		key := VersionTranslation{Language: st.Language, CodePage: st.CodePage}.key()
+		tables = append(tables, versionBlock(key, nil, 0, 1, strs))
+
+		var pair [4]byte
+		binary.LittleEndian.PutUint16(pair[0:], st.Language)
+		binary.LittleEndian.PutUint16(pair[2:], st.CodePage)
+		translationValue = append(translationValue, pair[:]...)
+	}
+	translations = append(translations, versionBlock("Translation", translationValue, uint16(len(translationValue)), 0, nil))
+
+	var children [][]byte
+	if len(tables) > 0 {
+		children = append(children, versionBlock("StringFileInfo", nil, 0, 1, tables))
+	}
+	children = append(children, versionBlock("VarFileInfo", nil, 0, 1, translations))
+
+	return versionBlock("VS_VERSION_INFO", fixed, vsFixedFileInfoSize, 0, children)
+}
+
+// versionBlock кодирует блок wLength/wValueLength/wType/szKey/Value/Children
+// с выравниванием полей по 4 байта
+func versionBlock(key string, value []byte, valueLength, typ uint16, children [][]byte) []byte {
+	var b bytes.Buffer
+	b.Write(make([]byte, 6))
+	b.Write(utf16z(key))
+	padTo4(&b)
+	if len(value) > 0 {
+		b.Write(value)
+	}
+	for _, child := range children {
+		padTo4(&b)
+		b.Write(child)
+	}
+
+	out := b.Bytes()
+	binary.LittleEndian.PutUint16(out[0:], uint16(len(out)))
+	binary.LittleEndian.PutUint16(out[2:], valueLength)
+	binary.LittleEndian.PutUint16(out[4:], typ)
+	return out
+}
+
+func padTo4(b *bytes.Buffer) {
+	for b.Len()%4 != 0 {
+		b.WriteByte(0)
+	}
+}
+
+// utf16z кодирует строку в UTF-16LE с завершающим нулем
+func utf16z(s string) []byte {
+	encoded := utf16.Encode([]rune(s))
+	out := make([]byte, (len(encoded)+1)*2)
+	for i, c := range encoded {
+		binary.LittleEndian.PutUint16(out[i*2:], c)
+	}
+	return out
+}
+
+// parseVersionResource разбирает структуру VS_VERSIONINFO
+func parseVersionResource(data []byte) (*VersionResource, error) {
+	root, err := parseVersionBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.key != "VS_VERSION_INFO" {
+		return nil, fmt.Errorf("malformed VERSIONINFO: unexpected key %q", root.key)
+	}
+
+	vr := &VersionResource{}
+	if len(root.value) >= vsFixedFileInfoSize && binary.LittleEndian.Uint32(root.value) == vsFixedFileInfoSignature {
+		v := root.value
+		fileMS, fileLS := binary.LittleEndian.Uint32(v[8:]), binary.LittleEndian.Uint32(v[12:])
+		prodMS, prodLS := binary.LittleEndian.Uint32(v[16:]), binary.LittleEndian.Uint32(v[20:])
+		vr.FileVersion = [4]uint16{uint16(fileMS >> 16), uint16(fileMS), uint16(fileLS >> 16), uint16(fileLS)}
+		vr.ProductVersion = [4]uint16{uint16(prodMS >> 16), uint16(prodMS), uint16(prodLS >> 16), uint16(prodLS)}
+		vr.FileFlagsMask = binary.LittleEndian.Uint32(v[24:])
+		vr.FileFlags = binary.LittleEndian.Uint32(v[28:])
+		vr.FileOS = binary.LittleEndian.Uint32(v[32:])
+		vr.FileType = binary.LittleEndian.Uint32(v[36:])
+		vr.FileSubtype = binary.LittleEndian.Uint32(v[40:])
+	}
+
+	for _, child := range root.children {
+		if child.key != "StringFileInfo" {
+			continue
+		}
+		for _, table := range child.children {
+			if len(table.key) != 8 {
+				continue
+			}
+			lang, err1 := strconv.ParseUint(table.key[:4], 16, 16)
+			cp, err2 := strconv.ParseUint(table.key[4:], 16, 16)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			st := VersionStringTable{Language: uint16(lang), CodePage: uint16(cp), Strings: map[string]string{}}
+			for _, s := range table.children {
+				st.Strings[s.key] = strings.TrimRight(decodeUTF16(s.value), "\x00")
+			}
+			vr.StringTables = append(vr.StringTables, st)
+		}
+	}
+	return vr, nil
+}
+
+type versionNode struct {
+	key      string
+	value    []byte
+	children []*versionNode
+}
+
+func parseVersionBlock(data []byte) (*versionNode, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("malformed VERSIONINFO: block too short")
+	}
+	length := int(binary.LittleEndian.Uint16(data[0:]))
+	valueLength := int(binary.LittleEndian.Uint16(data[2:]))
+	typ := binary.LittleEndian.Uint16(data[4:])
+	if length < 6 || length > len(data) {
+		return nil, fmt.Errorf("malformed VERSIONINFO: bad block length %d", length)
+	}
+	data = data[:length]
+
+	pos := 6
+	keyStart := pos
+	for pos+1 < len(data) && (data[pos] != 0 || data[pos+1] != 0) {
+		pos += 2
+	}
+	node := &versionNode{key: decodeUTF16(data[keyStart:pos])}
+	pos = alignInt(pos+2, 4)
+
+	if typ == 1 {
+		valueLength *= 2
+	}
+	if valueLength > 0 {
+		if pos+valueLength > len(data) {
+			valueLength = len(data) - pos
+		}
+		if valueLength > 0 {
+			node.value = data[pos : pos+valueLength]
+		}
+		pos = alignInt(pos+valueLength, 4)
+	}
+
+	for pos+6 <= len(data) {
+		child, err := parseVersionBlock(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, child)
+		pos = alignInt(pos+int(binary.LittleEndian.Uint16(data[pos:])), 4)
+	}
+	return node, nil
+}
+
+func alignInt(v, align int) int {
+	return (v + align - 1) / align * align
+}
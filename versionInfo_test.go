@@ -0,0 +1,70 @@
+package mkversions
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// testVersionResource строит ресурс с двумя языками и pre-release версией
+func testVersionResource() *VersionResource {
+	info := &Info{
+		Version: "1.4.2-rc.1",
+		AppMetadata: &AppMetadata{
+			ProgramName: "mkversions",
+			CompanyName: "Example Corp",
+			Description: "Version tool",
+			Legal:       "Copyright (c) Example Corp",
+			Translations: []VersionTranslation{
+				{Language: 0x0409, CodePage: 1200},
+				{Language: 0x0419, CodePage: 1200, Strings: map[string]string{"FileDescription": "Утилита версий"}},
+			},
+		},
+	}
+	return info.VersionResource()
+}
+
+// checkGolden сравнивает got с testdata/name; с -update перезаписывает файл
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: output differs from golden file (%d bytes, want %d)", name, len(got), len(want))
+	}
+}
+
+func TestVersionResourceBytesGolden(t *testing.T) {
+	vr := testVersionResource()
+	if vr.FileVersion != [4]uint16{1, 4, 2, 0} {
+		t.Errorf("FileVersion = %v", vr.FileVersion)
+	}
+	if vr.FileFlags&vsFFPrerelease == 0 {
+		t.Error("FileFlags has no VS_FF_PRERELEASE for a pre-release version")
+	}
+	checkGolden(t, "versioninfo.golden", vr.Bytes())
+}
+
+func TestParseVersionResourceRoundTrip(t *testing.T) {
+	vr := testVersionResource()
+	parsed, err := parseVersionResource(vr.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, vr) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", parsed, vr)
+	}
+}
@@ -0,0 +1,161 @@
+package mkversions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WorkTreeStatus описывает незакоммиченные изменения рабочей копии
+type WorkTreeStatus struct {
+	IsDirty        bool
+	ModifiedFiles  []string
+	UntrackedFiles []string
+	DiffHash       string
+}
+
+// GetGitWorkTreeStatus собирает список измененных и неотслеживаемых файлов
+// и вычисляет SHA-256 от незакоммиченного diff. Пути из exclude (относительно
+// текущего каталога) не учитываются, например генерируемый version_gen.go.
+func GetGitWorkTreeStatus(exclude ...string) (*WorkTreeStatus, error) {
+	return GetGitWorkTreeStatusContext(context.Background(), exclude...)
+}
+
+// GetGitWorkTreeStatusContext работает как GetGitWorkTreeStatus с контекстом
+func GetGitWorkTreeStatusContext(ctx context.Context, exclude ...string) (*WorkTreeStatus, error) {
+	return defaultRepo.WorkTreeStatus(ctx, exclude...)
+}
+
+// WorkTreeStatus работает как GetGitWorkTreeStatus для этого репозитория
+func (r *Repo) WorkTreeStatus(ctx context.Context, exclude ...string) (*WorkTreeStatus, error) {
+	return r.workTreeStatus(ctx, nil, exclude)
+}
+
+// workTreeStatus собирает состояние рабочей копии в paths (весь репозиторий, если пусто)
+func (r *Repo) workTreeStatus(ctx context.Context, paths, exclude []string) (*WorkTreeStatus, error) {
+	args := append([]string{"status", "--porcelain=v2", "-z", "--untracked-files=all"}, pathspec(paths, exclude)...)
+	stdout, err := r.run(ctx, args...)
+	if err != nil {
+		return nil, wrapGitError("failed to get Git status", err)
+	}
+
+	parsed := parsePorcelainStatus(stdout)
+	if err := r.fillDiffHash(ctx, &parsed.WorkTreeStatus, paths, exclude); err != nil {
+		return nil, err
+	}
+	return &parsed.WorkTreeStatus, nil
+}
+
+// porcelainStatus - разобранный вывод git status --porcelain=v2
+type porcelainStatus struct {
+	WorkTreeStatus
+	// Заголовки --branch: branch.oid, branch.head и другие
+	Headers map[string]string
+}
+
+// parsePorcelainStatus разбирает вывод git status --porcelain=v2 -z
+func parsePorcelainStatus(out string) *porcelainStatus {
+	status := &porcelainStatus{Headers: map[string]string{}}
+	records := strings.Split(out, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 2 {
+			continue
+		}
+
+		switch record[0] {
+		case '#':
+			if key, value, ok := strings.Cut(record[2:], " "); ok {
+				status.Headers[key] = value
+			}
+		case '?':
+			status.UntrackedFiles = append(status.UntrackedFiles, record[2:])
+		case '1':
+			// 1 XY sub mH mI mW hH hI path
+			if fields := strings.SplitN(record, " ", 9); len(fields) == 9 {
+				status.ModifiedFiles = append(status.ModifiedFiles, fields[8])
+			}
+		case '2':
+			// 2 XY sub mH mI mW hH hI Xscore path, следом исходный путь
+			if fields := strings.SplitN(record, " ", 10); len(fields) == 10 {
+				status.ModifiedFiles = append(status.ModifiedFiles, fields[9])
+			}
+			i++
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			if fields := strings.SplitN(record, " ", 11); len(fields) == 11 {
+				status.ModifiedFiles = append(status.ModifiedFiles, fields[10])
+			}
+		}
+	}
+	sort.Strings(status.ModifiedFiles)
+	sort.Strings(status.UntrackedFiles)
+	status.IsDirty = len(status.ModifiedFiles) > 0 || len(status.UntrackedFiles) > 0
+	return status
+}
+
+// fillDiffHash вычисляет DiffHash для грязной рабочей копии
+func (r *Repo) fillDiffHash(ctx context.Context, status *WorkTreeStatus, paths, exclude []string) error {
+	if !status.IsDirty {
+		return nil
+	}
+	hash, err := r.diffHash(ctx, status.UntrackedFiles, paths, exclude)
+	if err != nil {
+		return err
+	}
+	status.DiffHash = hash
+	return nil
+}
+
+// diffHash хэширует diff относительно HEAD вместе с содержимым неотслеживаемых файлов
+func (r *Repo) diffHash(ctx context.Context, untracked, paths, exclude []string) (string, error) {
+	args := append([]string{"diff", "HEAD", "--binary"}, pathspec(paths, exclude)...)
+	diff, err := r.run(ctx, args...)
+	if err != nil {
+		return "", wrapGitError("failed to get Git diff", err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(diff))
+
+	if len(untracked) > 0 {
+		topLevel, err := r.run(ctx, "rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", wrapGitError("failed to get Git top-level directory", err)
+		}
+		root := strings.TrimSpace(topLevel)
+
+		for _, path := range untracked {
+			data, err := os.ReadFile(filepath.Join(root, path))
+			if err != nil {
+				return "", fmt.Errorf("failed to read untracked file %s: %v", path, err)
+			}
+			fmt.Fprintf(h, "\x00%s\x00%d\x00", path, len(data))
+			h.Write(data)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pathspec строит pathspec, охватывающий paths (весь репозиторий, если
+// paths пуст) кроме exclude
+func pathspec(paths, exclude []string) []string {
+	if len(paths) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	args := []string{"--"}
+	if len(paths) == 0 {
+		args = append(args, ":/")
+	}
+	args = append(args, paths...)
+	for _, path := range exclude {
+		args = append(args, ":(exclude)"+path)
+	}
+	return args
+}